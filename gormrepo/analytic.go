@@ -0,0 +1,119 @@
+package gormrepo
+
+import (
+	"errors"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTransactionsUnsupported = errors.New("transactions aren't supported in read-optimized mode")
+
+type ReadOptimizedOptions struct {
+	// AsyncInsert has ClickHouse buffer inserts server-side and acknowledge
+	// them before they are written.
+	AsyncInsert bool
+	// Final reads with the FINAL modifier, merging row versions of
+	// ReplacingMergeTree and similar engines at query time.
+	Final bool
+}
+
+// ReadOptimized serves T from an analytic database such as ClickHouse,
+// which has no transactions: writes run without one and Transaction fails
+// with ErrTransactionsUnsupported.
+func (r *GenericRepository[T]) ReadOptimized(opts ReadOptimizedOptions) *GenericRepository[T] {
+	r.readOptimized = true
+	r.asyncInsert = opts.AsyncInsert
+	r.db = r.db.Session(&gorm.Session{SkipDefaultTransaction: true})
+	if opts.Final {
+		r.Final()
+	}
+	return r
+}
+
+// Final reads the table with the FINAL modifier.
+func (r *GenericRepository[T]) Final() *GenericRepository[T] {
+	r.db = r.db.Clauses(finalModifier{})
+	return r
+}
+
+// finalModifier builds the FROM clause of queries with FINAL after the
+// table. It takes the place of the FROM clause before the query callback
+// fills it in, which keeps the builder.
+type finalModifier struct{}
+
+func (finalModifier) Name() string {
+	return "FROM"
+}
+
+func (finalModifier) Build(clause.Builder) {}
+
+func (finalModifier) MergeClause(c *clause.Clause) {
+	c.Builder = buildFinalFrom
+}
+
+func buildFinalFrom(c clause.Clause, builder clause.Builder) {
+	from, _ := c.Expression.(clause.From)
+	builder.WriteString("FROM ")
+	if len(from.Tables) > 0 {
+		for i, table := range from.Tables {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteQuoted(table)
+		}
+	} else {
+		builder.WriteQuoted(clause.Table{Name: clause.CurrentTable})
+	}
+
+	// Deletes share the FROM clause but don't take FINAL
+	if stmt, ok := builder.(*gorm.Statement); !ok || slices.Contains(stmt.BuildClauses, "SELECT") {
+		builder.WriteString(" FINAL")
+	}
+	for _, join := range from.Joins {
+		builder.WriteByte(' ')
+		join.Build(builder)
+	}
+}
+
+// asyncInsertValues builds the VALUES clause of inserts with the settings
+// of ClickHouse asynchronous inserts, which go between the columns and
+// VALUES.
+type asyncInsertValues struct{}
+
+func (asyncInsertValues) Name() string {
+	return "VALUES"
+}
+
+func (asyncInsertValues) Build(clause.Builder) {}
+
+func (asyncInsertValues) MergeClause(c *clause.Clause) {
+	c.Builder = buildAsyncInsertValues
+}
+
+func buildAsyncInsertValues(c clause.Clause, builder clause.Builder) {
+	values, ok := c.Expression.(clause.Values)
+	if !ok || len(values.Columns) == 0 {
+		c.Builder = nil
+		c.Build(builder)
+		return
+	}
+
+	builder.WriteByte('(')
+	for i, column := range values.Columns {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(column)
+	}
+	builder.WriteString(") SETTINGS async_insert=1, wait_for_async_insert=0 VALUES ")
+	for i, value := range values.Values {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteByte('(')
+		builder.AddVar(builder, value...)
+		builder.WriteByte(')')
+	}
+}
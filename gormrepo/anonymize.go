@@ -0,0 +1,252 @@
+package gormrepo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrBlobsNotErasable = errors.New("blob store can't delete blobs")
+
+// ErasurePolicy configures Anonymize.
+type ErasurePolicy struct {
+	// Cascade lists associations whose rows are anonymized along with the
+	// entity, e.g. "Addresses" or "Orders.Payments".
+	Cascade []string
+	// Salt is mixed into hashed values so they can't be looked up in
+	// precomputed tables.
+	Salt string
+	// Log receives a record of each anonymized row, without values.
+	Log OperationSink
+}
+
+// Anonymize erases the personal data of the entity with the given id and
+// of the associations in policy.Cascade, in one transaction. Fields tagged
+// `pii:"erase"` are set to NULL, or their zero value when not nullable;
+// `pii:"hash"` fields are replaced by a salted SHA-256 of their value. The
+// blobs of such fields stored with WithBlobStore are deleted once no row
+// references them, which fails with ErrBlobsNotErasable unless the store
+// implements BlobDeleter. The blobs are deleted, the cache entries of the
+// rows dropped and policy.Log written once the erasure is committed.
+func (r *GenericRepository[T]) Anonymize(id any, policy ErasurePolicy) error {
+	if r.lastError != nil {
+		return r.lastError
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return gorm.ErrPrimaryKeyRequired
+	}
+
+	byID := map[string]any{s.PrioritizedPrimaryField.DBName: id}
+	var records []OperationRecord
+	var erased, unreferenced []string
+	err = r.writeWhere(OpAnonymize, byID, func(db *gorm.DB) error {
+		// The conditions of the chain would also apply to the associated rows
+		return db.Session(&gorm.Session{NewDB: true}).Transaction(func(tx *gorm.DB) error {
+			entity := new(T)
+			// Soft-deleted rows hold personal data too
+			query := tx.Unscoped().Where(byID)
+			for _, association := range policy.Cascade {
+				query = query.Preload(association)
+			}
+			if err := query.Take(entity).Error; err != nil {
+				return err
+			}
+
+			rows := []reflect.Value{reflect.ValueOf(entity).Elem()}
+			schemas := []*schema.Schema{s}
+			for _, association := range policy.Cascade {
+				related, relatedSchema, err := associatedRows(reflect.ValueOf(entity).Elem(), s, association)
+				if err != nil {
+					return err
+				}
+				for _, row := range related {
+					rows = append(rows, row)
+					schemas = append(schemas, relatedSchema)
+				}
+			}
+
+			var hashes []string
+			for i, row := range rows {
+				rec, blobs, err := anonymizeRow(tx, schemas[i], row, policy.Salt)
+				if err != nil {
+					return err
+				}
+				if rec != nil {
+					records = append(records, *rec)
+					if pk := schemas[i].PrioritizedPrimaryField; pk != nil {
+						value, _ := pk.ValueOf(tx.Statement.Context, row)
+						erased = append(erased, rowCacheKey(schemas[i].Table, reflect.Indirect(reflect.ValueOf(value)).Interface()))
+					}
+				}
+				hashes = append(hashes, blobs...)
+			}
+			unreferenced, err = r.unreferencedBlobs(tx, schemas, hashes)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := r.context()
+	return r.onCommit(func() error {
+		r.dropCached(erased)
+		for _, hash := range unreferenced {
+			if err := r.blobStore.(BlobDeleter).DeleteBlob(ctx, hash); err != nil {
+				return fmt.Errorf("deleting blob %s: %w", hash, err)
+			}
+		}
+		if policy.Log == nil || len(records) == 0 {
+			return nil
+		}
+		return policy.Log.WriteOperations(ctx, records)
+	})
+}
+
+// unreferencedBlobs returns the hashes no blob field of the schemas or of
+// the registered models references any more, failing when they can't be
+// deleted.
+func (r *GenericRepository[T]) unreferencedBlobs(tx *gorm.DB, schemas []*schema.Schema, hashes []string) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	if _, ok := r.blobStore.(BlobDeleter); !ok {
+		return nil, ErrBlobsNotErasable
+	}
+
+	tables := map[string]*schema.Schema{}
+	for _, s := range schemas {
+		tables[s.Table] = s
+	}
+	for _, model := range registeredModels() {
+		s, err := parseSchema(tx, model)
+		if err != nil {
+			return nil, err
+		}
+		tables[s.Table] = s
+	}
+
+	var unreferenced []string
+	for _, hash := range slices.Compact(slices.Sorted(slices.Values(hashes))) {
+		referenced := false
+		for table, s := range tables {
+			for _, field := range blobFields(s) {
+				var count int64
+				err := tx.Session(&gorm.Session{NewDB: true}).Table(table).
+					Where(clause.Eq{Column: clause.Column{Name: field.DBName}, Value: blobRefPrefix + hash}).
+					Count(&count).Error
+				if err != nil {
+					return nil, err
+				}
+				referenced = referenced || count > 0
+			}
+		}
+		if !referenced {
+			unreferenced = append(unreferenced, hash)
+		}
+	}
+	return unreferenced, nil
+}
+
+// associatedRows follows a dotted association path from entity and returns
+// the rows found at its end.
+func associatedRows(entity reflect.Value, s *schema.Schema, path string) ([]reflect.Value, *schema.Schema, error) {
+	rows := []reflect.Value{entity}
+	for _, name := range strings.Split(path, ".") {
+		rel, ok := s.Relationships.Relations[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s has no association %s", ErrUnknownField, s.Name, name)
+		}
+
+		var next []reflect.Value
+		for _, row := range rows {
+			forEachEntity(row.FieldByIndex(rel.Field.StructField.Index).Addr().Interface(), func(related reflect.Value) error {
+				next = append(next, related)
+				return nil
+			})
+		}
+		rows, s = next, rel.FieldSchema
+	}
+	return rows, s, nil
+}
+
+// anonymizeRow overwrites the PII columns of one row, returning the record
+// of the erasure, or nil when s has no PII fields, and the hashes of the
+// blobs the row referenced in them.
+func anonymizeRow(tx *gorm.DB, s *schema.Schema, row reflect.Value, salt string) (*OperationRecord, []string, error) {
+	updates := map[string]any{}
+	var columns, hashes []string
+	external := blobFields(s)
+	for _, field := range s.Fields {
+		mode := field.Tag.Get("pii")
+		if mode == "" || field.DBName == "" {
+			continue
+		}
+		if slices.Contains(external, field) {
+			if hash, ok := strings.CutPrefix(string(blobBytes(field.ReflectValueOf(tx.Statement.Context, row))), blobRefPrefix); ok {
+				hashes = append(hashes, hash)
+			}
+		}
+
+		switch mode {
+		case "erase":
+			if !field.NotNull && isNullable(field.FieldType) {
+				updates[field.DBName] = nil
+			} else {
+				updates[field.DBName] = reflect.Zero(field.FieldType).Interface()
+			}
+		case "hash":
+			value, isZero := field.ValueOf(tx.Statement.Context, row)
+			if isZero {
+				continue
+			}
+			sum := sha256.Sum256([]byte(salt + fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)).Interface())))
+			updates[field.DBName] = hex.EncodeToString(sum[:])
+		default:
+			return nil, nil, fmt.Errorf("unknown pii mode %q on %s.%s", mode, s.Name, field.Name)
+		}
+		columns = append(columns, field.DBName)
+	}
+	if len(updates) == 0 {
+		return nil, nil, nil
+	}
+
+	keys := entityKeys(tx.Statement.Context, s, row)
+	if keys == nil {
+		return nil, nil, fmt.Errorf("%s row has no primary key", s.Name)
+	}
+	if err := tx.Table(s.Table).Where(keys).Updates(updates).Error; err != nil {
+		return nil, nil, err
+	}
+	return &OperationRecord{
+		At:      time.Now(),
+		Actor:   ActorFromContext(tx.Statement.Context),
+		Kind:    OpAnonymize,
+		Table:   s.Table,
+		Keys:    keys,
+		Columns: columns,
+	}, hashes, nil
+}
+
+// isNullable reports whether values of typ can hold NULL.
+func isNullable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	_, ok := reflect.New(typ).Interface().(interface{ Scan(any) error })
+	return ok
+}
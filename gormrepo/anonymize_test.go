@@ -0,0 +1,86 @@
+package gormrepo
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type piiItem struct {
+	ID    int64
+	Name  string  `pii:"erase"`
+	Email *string `pii:"hash"`
+	Plan  string
+}
+
+func TestAnonymizeErasesAndDropsCachedRow(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	sink := &recordingSink{fake: fake}
+	r := New[piiItem](db).WithCache(NewMemoryCache(), time.Minute)
+
+	columns := []string{"id", "name", "email", "plan"}
+	fake.queue(columns, []driver.Value{int64(7), "Ann", "ann@example.com", "pro"})
+	if _, err := r.FindByIDCached(7); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.queue(columns, []driver.Value{int64(7), "Ann", "ann@example.com", "pro"})
+	if err := r.Anonymize(7, ErasurePolicy{Salt: "pepper", Log: sink}); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("pepper" + "ann@example.com"))
+	want := fmt.Sprintf(`UPDATE "pii_items" SET "email"=?,"name"=? WHERE "pii_items"."id" = ? [%s  7]`, hex.EncodeToString(sum[:]))
+	if got := fake.ranLike(`UPDATE "pii_items"`); len(got) != 1 || got[0] != want {
+		t.Errorf("ran %q, want %s", got, want)
+	}
+
+	if len(sink.records) != 1 || sink.after[0] != "COMMIT" {
+		t.Fatalf("logged %+v after %q", sink.records, sink.after)
+	}
+	rec := sink.records[0]
+	if rec.Kind != OpAnonymize || !slices.Equal(rec.Columns, []string{"name", "email"}) || rec.Changes != nil {
+		t.Errorf("logged %+v", rec)
+	}
+
+	// The erased row is read again
+	fake.queue(columns, []driver.Value{int64(7), "", hex.EncodeToString(sum[:]), "pro"})
+	item, err := r.FindByIDCached(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != "" || strings.Contains(*item.Email, "@") {
+		t.Errorf("got %+v", item)
+	}
+	if got := fake.ranLike(`SELECT * FROM "pii_items"`); len(got) != 3 {
+		t.Errorf("ran %q, want the cached lookup, the erasure and a new lookup", got)
+	}
+}
+
+func TestAnonymizeWaitsForTheTransaction(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	sink := &recordingSink{fake: fake}
+	r := New[piiItem](db)
+
+	err := r.Transaction(func(tx *GenericRepository[piiItem]) error {
+		fake.queue([]string{"id", "name"}, []driver.Value{int64(7), "Ann"})
+		if err := tx.Anonymize(7, ErasurePolicy{Log: sink}); err != nil {
+			return err
+		}
+		if len(sink.records) != 0 {
+			t.Errorf("logged %+v before the commit", sink.records)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.records) != 1 || sink.after[0] != "COMMIT" {
+		t.Errorf("logged %+v after %q", sink.records, sink.after)
+	}
+}
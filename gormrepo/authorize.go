@@ -0,0 +1,32 @@
+package gormrepo
+
+import (
+	"context"
+	"reflect"
+)
+
+// Authorizer decides whether an operation may run. It is called once per
+// written entity, or once with a nil entity for queries and writes driven by
+// conditions only; those can be narrowed by adding conditions to op.Stmt.
+type Authorizer func(ctx context.Context, op Operation, entity any) error
+
+// WithAuthorizer checks every read and write with fn before it runs. The
+// operation fails with the error fn returns.
+func (r *GenericRepository[T]) WithAuthorizer(fn Authorizer) *GenericRepository[T] {
+	return r.Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			var err error
+			if op.Entity == nil {
+				err = fn(op.Ctx, op, nil)
+			} else {
+				err = forEachEntity(op.Entity, func(entity reflect.Value) error {
+					return fn(op.Ctx, op, entity.Addr().Interface())
+				})
+			}
+			if err != nil {
+				return err
+			}
+			return next(op)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package gormrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// blobRefPrefix marks column values that reference an out-of-row blob.
+const blobRefPrefix = "blob:sha256:"
+
+// BlobStore keeps the content of large columns out of their table, keyed by
+// the SHA-256 of the content. Object stores can implement it directly.
+type BlobStore interface {
+	PutBlob(ctx context.Context, hash string, data []byte) error
+	GetBlob(ctx context.Context, hash string) ([]byte, error)
+}
+
+// BlobDeleter is implemented by blob stores that can delete blobs, which
+// Anonymize needs to erase personal data stored out of row.
+type BlobDeleter interface {
+	DeleteBlob(ctx context.Context, hash string) error
+}
+
+type Blob struct {
+	Hash string `gorm:"primaryKey;size:64"`
+	Data []byte
+}
+
+func (Blob) TableName() string {
+	return "blobs"
+}
+
+type tableBlobStore struct {
+	db *gorm.DB
+}
+
+// NewTableBlobStore stores blobs in the blobs table of db.
+func NewTableBlobStore(db *gorm.DB) BlobStore {
+	return &tableBlobStore{db: db}
+}
+
+func (s *tableBlobStore) PutBlob(ctx context.Context, hash string, data []byte) error {
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Blob{Hash: hash, Data: data}).Error
+}
+
+func (s *tableBlobStore) GetBlob(ctx context.Context, hash string) ([]byte, error) {
+	var blob Blob
+	if err := s.db.WithContext(ctx).Where("hash = ?", hash).Take(&blob).Error; err != nil {
+		return nil, err
+	}
+	return blob.Data, nil
+}
+
+func (s *tableBlobStore) DeleteBlob(ctx context.Context, hash string) error {
+	return s.db.WithContext(ctx).Where("hash = ?", hash).Delete(&Blob{}).Error
+}
+
+// WithBlobStore stores the string and []byte fields tagged blob:"external"
+// in store. Their columns hold a reference to the content, which writes put
+// in the store and reads resolve, so callers only see the content. Identical
+// content is stored once.
+func (r *GenericRepository[T]) WithBlobStore(store BlobStore) *GenericRepository[T] {
+	r.blobStore = store
+	return r
+}
+
+func blobFields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if field.Tag.Get("blob") != "external" || field.DBName == "" {
+			continue
+		}
+		if kind := field.FieldType.Kind(); kind == reflect.String || field.FieldType == reflect.TypeOf([]byte(nil)) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// externalizeBlobs moves the external fields of target to the blob store,
+// leaving references in the entities until restore puts the content back.
+func (r *GenericRepository[T]) externalizeBlobs(kind OperationKind, target any) (restore func(), err error) {
+	restore = func() {}
+	if r.blobStore == nil || (kind != OpCreate && kind != OpUpdate && kind != OpUpsert) {
+		return restore, nil
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return restore, err
+	}
+	fields := blobFields(s)
+	if len(fields) == 0 {
+		return restore, nil
+	}
+
+	ctx := r.context()
+	var contents []func()
+	restore = func() {
+		for _, put := range contents {
+			put()
+		}
+	}
+	err = forEachEntity(target, func(entity reflect.Value) error {
+		for _, field := range fields {
+			value := field.ReflectValueOf(ctx, entity)
+			data := blobBytes(value)
+			if len(data) == 0 || strings.HasPrefix(string(data), blobRefPrefix) {
+				continue
+			}
+
+			sum := sha256.Sum256(data)
+			hash := hex.EncodeToString(sum[:])
+			if err := r.blobStore.PutBlob(ctx, hash, data); err != nil {
+				return fmt.Errorf("storing %s blob: %w", field.Name, err)
+			}
+
+			content := reflect.ValueOf(value.Interface())
+			contents = append(contents, func() { value.Set(content) })
+			setBlobBytes(value, []byte(blobRefPrefix+hash))
+		}
+		return nil
+	})
+	if err != nil {
+		restore()
+	}
+	return restore, err
+}
+
+// resolveBlobs replaces the blob references of loaded entities by their
+// content.
+func (r *GenericRepository[T]) resolveBlobs(entities ...*T) error {
+	if r.blobStore == nil || len(entities) == 0 {
+		return nil
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	fields := blobFields(s)
+
+	ctx := r.context()
+	fetched := map[string][]byte{}
+	for _, entity := range entities {
+		if entity == nil {
+			continue
+		}
+		for _, field := range fields {
+			value := field.ReflectValueOf(ctx, reflect.ValueOf(entity).Elem())
+			hash, ok := strings.CutPrefix(string(blobBytes(value)), blobRefPrefix)
+			if !ok {
+				continue
+			}
+			data, ok := fetched[hash]
+			if !ok {
+				if data, err = r.blobStore.GetBlob(ctx, hash); err != nil {
+					return fmt.Errorf("loading %s blob %s: %w", field.Name, hash, err)
+				}
+				fetched[hash] = data
+			}
+			setBlobBytes(value, data)
+		}
+	}
+	return nil
+}
+
+func blobBytes(value reflect.Value) []byte {
+	if value.Kind() == reflect.String {
+		return []byte(value.String())
+	}
+	return value.Bytes()
+}
+
+func setBlobBytes(value reflect.Value, data []byte) {
+	if value.Kind() == reflect.String {
+		value.SetString(string(data))
+	} else {
+		value.SetBytes(data)
+	}
+}
@@ -0,0 +1,128 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// CircuitBreaker fails operations fast with ErrCircuitOpen once the database
+// failed threshold times in a row. After openFor it lets up to probes
+// operations through; the first success closes it again and a failure
+// reopens it. Share one breaker between the repositories of a database.
+type CircuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	probes    int
+
+	// IsFailure reports whether err counts against the database. By default
+	// every error does except not found and cancellation by the caller.
+	IsFailure func(err error) bool
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	inFlight int // Probes running while half-open
+	openedAt time.Time
+}
+
+func NewCircuitBreaker(threshold int, openFor time.Duration, probes int) *CircuitBreaker {
+	return &CircuitBreaker{threshold: max(threshold, 1), openFor: openFor, probes: max(probes, 1)}
+}
+
+// WithCircuitBreaker runs every operation of the repository through cb.
+func (r *GenericRepository[T]) WithCircuitBreaker(cb *CircuitBreaker) *GenericRepository[T] {
+	return r.Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			probe, err := cb.allow()
+			if err != nil {
+				return err
+			}
+			err = next(op)
+			cb.record(probe, err)
+			return err
+		}
+	})
+}
+
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// allow reports whether an operation may run and whether it is a probe.
+func (cb *CircuitBreaker) allow() (bool, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen {
+		if time.Since(cb.openedAt) < cb.openFor {
+			return false, ErrCircuitOpen
+		}
+		cb.state = CircuitHalfOpen
+		cb.inFlight = 0
+	}
+	if cb.state == CircuitHalfOpen {
+		if cb.inFlight >= cb.probes {
+			return false, ErrCircuitOpen
+		}
+		cb.inFlight++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (cb *CircuitBreaker) record(probe bool, err error) {
+	failed := err != nil && cb.isFailure(err)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe {
+		cb.inFlight--
+	}
+	switch {
+	case cb.state == CircuitOpen:
+		// Started before the circuit (re)opened
+	case !failed:
+		if cb.state == CircuitHalfOpen && !probe {
+			return
+		}
+		cb.state = CircuitClosed
+		cb.failures = 0
+	case cb.state == CircuitHalfOpen:
+		cb.open()
+	default:
+		cb.failures++
+		if cb.failures >= cb.threshold {
+			cb.open()
+		}
+	}
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.state = CircuitOpen
+	cb.openedAt = time.Now()
+	cb.failures = 0
+}
+
+func (cb *CircuitBreaker) isFailure(err error) bool {
+	if cb.IsFailure != nil {
+		return cb.IsFailure(err)
+	}
+	return !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,67 @@
+package gormrepo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	cb := NewCircuitBreaker(2, 20*time.Millisecond, 1)
+	get := func() error {
+		_, err := New[testItem](db).WithCircuitBreaker(cb).Get()
+		return err
+	}
+
+	fake.failOn = "SELECT"
+	for i := 0; i < 2; i++ {
+		if err := get(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("query %d: got %v, want the database failure", i, err)
+		}
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state is %v after two failures, want open", cb.State())
+	}
+
+	// Open, the database isn't asked
+	ran := len(fake.ran())
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if got := fake.ran()[ran:]; len(got) != 0 {
+		t.Errorf("ran %q while open", got)
+	}
+
+	// A failing probe opens it again
+	time.Sleep(30 * time.Millisecond)
+	if err := get(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want the database failure", err)
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state is %v after a failed probe, want open", cb.State())
+	}
+
+	// A successful probe closes it
+	time.Sleep(30 * time.Millisecond)
+	fake.failOn = ""
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Errorf("state is %v after a successful probe, want closed", cb.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresNotFound(t *testing.T) {
+	db, _ := newTestDB(t, "postgres", "16.2")
+	cb := NewCircuitBreaker(1, time.Minute, 1)
+	for i := 0; i < 2; i++ {
+		if _, err := New[testItem](db).WithCircuitBreaker(cb).FindByID(7).First(); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	}
+	if cb.State() != CircuitClosed {
+		t.Errorf("state is %v, want closed", cb.State())
+	}
+}
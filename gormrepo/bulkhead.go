@@ -0,0 +1,39 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrQueueTimeout = errors.New("timed out waiting for a free operation slot")
+
+// WithMaxConcurrent lets at most n operations of the repository, and of the
+// repositories derived from it, run at once. Others wait up to queueTimeout
+// for a slot, or without limit when it is zero, then fail with
+// ErrQueueTimeout; a cancelled context ends the wait too.
+func (r *GenericRepository[T]) WithMaxConcurrent(n int, queueTimeout time.Duration) *GenericRepository[T] {
+	slots := make(chan struct{}, max(n, 1))
+
+	return r.Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			var timeout <-chan time.Time
+			if queueTimeout > 0 {
+				timer := time.NewTimer(queueTimeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-timeout:
+				return fmt.Errorf("%w after %s", ErrQueueTimeout, queueTimeout)
+			case <-op.Ctx.Done():
+				return op.Ctx.Err()
+			}
+			defer func() { <-slots }()
+
+			return next(op)
+		}
+	})
+}
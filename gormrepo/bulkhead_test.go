@@ -0,0 +1,41 @@
+package gormrepo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentQueuesOperations(t *testing.T) {
+	db, _ := newTestDB(t, "postgres", "16.2")
+	started, release := make(chan struct{}), make(chan struct{})
+	r := New[testItem](db).WithMaxConcurrent(1, 20*time.Millisecond).Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			select {
+			case started <- struct{}{}:
+				<-release
+			default:
+			}
+			return next(op)
+		}
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := r.clone(db).Get()
+		done <- err
+	}()
+	<-started
+
+	// The slot is taken until the first query is released
+	if _, err := r.clone(db).Get(); !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("got %v, want ErrQueueTimeout", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.clone(db).Get(); err != nil {
+		t.Fatal(err)
+	}
+}
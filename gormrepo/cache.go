@@ -0,0 +1,242 @@
+package gormrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Cache is the storage used by the caching features of this package. Values
+// are opaque bytes; implementations may be in-process or remote (Redis, memcached).
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.Delete(key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key; a zero ttl keeps it until deleted.
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	entry := memoryCacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// flightGroup runs one call per key at a time; concurrent callers with the
+// same key wait for it and share its result, so an expired hot key reaches
+// the database once.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = fn()
+	return call.value, call.err
+}
+
+const (
+	entityCachePrefix     = "gormrepo:entity:"
+	entityMissCachePrefix = "gormrepo:entity-miss:"
+)
+
+// WithCache makes FindByIDCached keep the entities it loads in cache for
+// ttl. Writes of entities through the repository drop their entries;
+// conditional writes such as UpdateWhere don't, so rows they change are
+// served stale until their entry expires.
+func (r *GenericRepository[T]) WithCache(cache Cache, ttl time.Duration) *GenericRepository[T] {
+	r.cache = cache
+	r.cacheTTL = ttl
+	r.flights = &flightGroup{}
+	return r
+}
+
+// WithNegativeCache makes the cache set by WithCache also remember IDs
+// FindByIDCached found missing for ttl, so repeated lookups of deleted or
+// nonexistent rows stay off the database. Keep ttl short: a row created
+// through another node is only seen once its entry expires.
+func (r *GenericRepository[T]) WithNegativeCache(ttl time.Duration) *GenericRepository[T] {
+	r.missTTL = ttl
+	return r
+}
+
+// FindByIDCached returns the entity with the given id, from the cache set by
+// WithCache when it holds it. It returns ErrNotFound for missing rows. The
+// conditions of the chain only apply when the entity is loaded, so
+// repositories sharing a cache should share their configuration. Reads
+// restricted by WithOwnership bypass the cache.
+func (r *GenericRepository[T]) FindByIDCached(id int64) (*T, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if r.cache == nil {
+		return r.FindByID(id).First()
+	}
+	if r.ownershipApplies() {
+		return r.clone(r.db.Session(&gorm.Session{})).FindByID(id).First()
+	}
+
+	key, err := r.entityCacheKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := r.cache.Get(entityCachePrefix + key); ok {
+		entity := new(T)
+		if err := json.Unmarshal(cached, entity); err == nil {
+			return entity, nil
+		}
+	}
+	if _, missing := r.cache.Get(entityMissCachePrefix + key); missing && r.missTTL > 0 {
+		return nil, ErrNotFound
+	}
+
+	encoded, err := r.flights.do(key, func() ([]byte, error) {
+		entity, err := r.clone(r.db.Session(&gorm.Session{})).FindByID(id).First()
+		if errors.Is(err, ErrNotFound) && r.missTTL > 0 {
+			r.cache.Set(entityMissCachePrefix+key, nil, r.missTTL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(entity)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding %s %s for the cache: %w", reflect.TypeFor[T]().Name(), key, err)
+		}
+		r.cache.Set(entityCachePrefix+key, encoded, r.cacheTTL)
+		return encoded, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entity := new(T)
+	if err := json.Unmarshal(encoded, entity); err != nil {
+		return nil, err
+	}
+	r.currentResult = entity
+	return entity, nil
+}
+
+func (r *GenericRepository[T]) entityCacheKey(id any) (string, error) {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return "", err
+	}
+	return rowCacheKey(s.Table, id), nil
+}
+
+func rowCacheKey(table string, id any) string {
+	return table + ":" + fmt.Sprint(id)
+}
+
+// cacheKeys returns the cache keys of the entities in target, whose entries,
+// found or missing, are dropped once their write is committed.
+func (r *GenericRepository[T]) cacheKeys(target any) []string {
+	if r.cache == nil {
+		return nil
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil || s.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	var keys []string
+	forEachEntity(target, func(entity reflect.Value) error {
+		if id, isZero := s.PrioritizedPrimaryField.ValueOf(r.context(), entity); !isZero {
+			keys = append(keys, rowCacheKey(s.Table, reflect.Indirect(reflect.ValueOf(id)).Interface()))
+		}
+		return nil
+	})
+	return keys
+}
+
+func (r *GenericRepository[T]) dropCached(keys []string) {
+	for _, key := range keys {
+		r.uncacheKey(key)
+	}
+}
+
+func (r *GenericRepository[T]) uncacheID(id any) {
+	if r.cache == nil {
+		return
+	}
+	if key, err := r.entityCacheKey(id); err == nil {
+		r.uncacheKey(key)
+	}
+}
+
+func (r *GenericRepository[T]) uncacheKey(key string) {
+	if r.cache == nil {
+		return
+	}
+	r.cache.Delete(entityCachePrefix + key)
+	r.cache.Delete(entityMissCachePrefix + key)
+}
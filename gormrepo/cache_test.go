@@ -0,0 +1,40 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFindByIDCachedRemembersMisses(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	r := New[testItem](db).WithCache(NewMemoryCache(), time.Minute).WithNegativeCache(time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.FindByIDCached(7); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	}
+	if got := fake.ranLike(" FROM "); len(got) != 1 {
+		t.Errorf("ran %q, want one lookup", got)
+	}
+
+	// Creating the row drops the miss
+	if err := r.Create(&testItem{ID: 7, Name: "lamp"}).Error(); err != nil {
+		t.Fatal(err)
+	}
+	fake.queue([]string{"id", "name", "price"}, []driver.Value{int64(7), "lamp", int64(12)})
+	for i := 0; i < 2; i++ {
+		item, err := r.FindByIDCached(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Name != "lamp" {
+			t.Errorf("got %+v", item)
+		}
+	}
+	if got := fake.ranLike(" FROM "); len(got) != 2 {
+		t.Errorf("ran %q, want two lookups", got)
+	}
+}
@@ -0,0 +1,89 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrQueryCancelled = errors.New("query cancelled")
+
+// QueryCancelledError is returned when the caller's context was cancelled
+// while an operation was running. Deadline expiries are not reported this way,
+// so client disconnects can be told apart from slow queries.
+type QueryCancelledError struct {
+	Operation OperationKind
+	Elapsed   time.Duration
+	// InTransaction reports that the operation ran inside a transaction, so
+	// nothing it did will be committed. Outside a transaction a cancelled write
+	// may or may not have been applied.
+	InTransaction bool
+	Err           error
+}
+
+func (e *QueryCancelledError) Error() string {
+	return fmt.Sprintf("%s cancelled after %s: %v", e.Operation, e.Elapsed, e.Err)
+}
+
+func (e *QueryCancelledError) Is(target error) bool {
+	return target == ErrQueryCancelled
+}
+
+func (e *QueryCancelledError) Unwrap() error {
+	return e.Err
+}
+
+// run executes fn as one repository operation on target, which is nil unless
+// entities are being written.
+func (r *GenericRepository[T]) run(kind OperationKind, target any, fn func() error) error {
+	return r.runOn(r.db, kind, target, nil, fn)
+}
+
+// runOn is run for operations executed on db rather than on the chain, such
+// as writes, so middleware sees the statement that runs. Work the middleware
+// defers until the write commits is added to work, or when it is nil handled
+// like the work of write.
+func (r *GenericRepository[T]) runOn(db *gorm.DB, kind OperationKind, target any, work *pendingWork[T], fn func() error) error {
+	defer func(previous OperationKind) { r.operation = previous }(r.operation)
+	r.operation = kind
+
+	deferred := work
+	if deferred == nil {
+		deferred = &pendingWork[T]{}
+	}
+
+	start := time.Now()
+	err := r.handle(db, kind, target, deferred, fn)
+	if err == nil {
+		if work != nil || len(deferred.operations) == 0 {
+			return nil
+		}
+		if r.pending != nil {
+			r.pending.add(deferred)
+			return nil
+		}
+		if err := r.committed(deferred); err != nil {
+			return &committedError{err}
+		}
+		return nil
+	}
+
+	ctx := db.Statement.Context
+	if errors.Is(err, context.Canceled) || (ctx != nil && errors.Is(ctx.Err(), context.Canceled)) {
+		return &QueryCancelledError{
+			Operation:     kind,
+			Elapsed:       time.Since(start),
+			InTransaction: inTransaction(db),
+			Err:           err,
+		}
+	}
+	return err
+}
+
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
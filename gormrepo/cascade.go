@@ -0,0 +1,184 @@
+package gormrepo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// CascadePlan configures DeleteCascade.
+type CascadePlan struct {
+	// Associations lists the has-one, has-many and many-to-many associations
+	// deleted with the entity, e.g. "Orders" or "Orders.Payments". A path
+	// includes its prefixes. Many-to-many associations only lose their join
+	// rows.
+	Associations []string
+	// BatchSize is the number of rows deleted per statement, 500 when zero.
+	BatchSize int
+}
+
+// cascadeStep is one association path, resolved to its relationships.
+type cascadeStep struct {
+	path []*schema.Relationship
+}
+
+// DeleteCascade deletes the entity with the given id and the rows of the
+// associations in plan, children before their parents, in one transaction,
+// for schemas without ON DELETE CASCADE. Soft-deletable rows are soft
+// deleted; those with a deletion_group column share a group id that
+// RestoreCascade uses to undelete them together.
+func (r *GenericRepository[T]) DeleteCascade(id any, plan CascadePlan) error {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return gorm.ErrPrimaryKeyRequired
+	}
+	steps, err := cascadeSteps(s, plan.Associations)
+	if err != nil {
+		return err
+	}
+	batchSize := plan.BatchSize
+	if batchSize <= 0 {
+		batchSize = conflictBatchSize
+	}
+	pk := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName}, Value: id}
+
+	return r.write(OpDelete, nil, func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			// The entity must be visible to the chain before anything goes
+			var count int64
+			if err := tx.Model(new(T)).Where(pk).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			group, err := newDeletionGroup()
+			if err != nil {
+				return err
+			}
+			fresh := tx.Session(&gorm.Session{NewDB: true})
+			for _, step := range steps {
+				if err := deleteCascadeStep(fresh, s, pk, step, batchSize, group); err != nil {
+					return err
+				}
+			}
+			if err := tx.Where(pk).Delete(new(T)).Error; err != nil {
+				return err
+			}
+			return stampDeletionGroup(fresh, s, pk, group)
+		})
+	})
+}
+
+// cascadeSteps resolves the association paths and their prefixes, deepest
+// first.
+func cascadeSteps(s *schema.Schema, associations []string) ([]cascadeStep, error) {
+	seen := map[string]bool{}
+	var steps []cascadeStep
+	for _, association := range associations {
+		names := strings.Split(association, ".")
+		current := s
+		var path []*schema.Relationship
+		for i, name := range names {
+			rel, ok := current.Relationships.Relations[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s has no association %s", ErrUnknownField, current.Name, name)
+			}
+			switch {
+			case rel.Type == schema.BelongsTo:
+				return nil, fmt.Errorf("cannot cascade to %s.%s: it belongs to %s", current.Name, name, current.Name)
+			case rel.Type == schema.Many2Many && i < len(names)-1:
+				return nil, fmt.Errorf("cannot cascade beyond many-to-many %s.%s", current.Name, name)
+			}
+			path = append(path, rel)
+			current = rel.FieldSchema
+
+			key := strings.Join(names[:i+1], ".")
+			if !seen[key] {
+				seen[key] = true
+				steps = append(steps, cascadeStep{path: slices.Clone(path)})
+			}
+		}
+	}
+	slices.SortStableFunc(steps, func(a, b cascadeStep) int { return len(b.path) - len(a.path) })
+	return steps, nil
+}
+
+// deleteCascadeStep deletes in batches the rows at the end of step's path
+// that descend from the root row matching root, stamping soft-deleted rows
+// with the deletion group.
+func deleteCascadeStep(db *gorm.DB, s *schema.Schema, root clause.Expression, step cascadeStep, batchSize int, group string) error {
+	parents := db.Model(reflect.New(s.ModelType).Interface()).Where(root)
+	for _, rel := range step.path[:len(step.path)-1] {
+		parents = childRows(db, rel, parents)
+	}
+
+	last := step.path[len(step.path)-1]
+	if last.Type == schema.Many2Many {
+		join := db.Table(last.JoinTable.Table)
+		for _, ref := range last.References {
+			if ref.OwnPrimaryKey {
+				join = join.Where("? IN (?)", clause.Column{Name: ref.ForeignKey.DBName}, parents.Session(&gorm.Session{}).Select(ref.PrimaryKey.DBName))
+			}
+		}
+		return join.Delete(nil).Error
+	}
+
+	child := last.FieldSchema
+	if child.PrioritizedPrimaryField == nil {
+		return fmt.Errorf("cannot cascade to %s: %w", child.Name, gorm.ErrPrimaryKeyRequired)
+	}
+	childPK := child.PrioritizedPrimaryField.DBName
+	for {
+		ids := reflect.New(reflect.SliceOf(child.PrioritizedPrimaryField.FieldType))
+		if err := childRows(db, last, parents).Limit(batchSize).Pluck(childPK, ids.Interface()).Error; err != nil {
+			return err
+		}
+		if ids.Elem().Len() == 0 {
+			return nil
+		}
+		batch := clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: childPK}, Values: toInterfaceSlice(ids.Elem())}
+		err := db.Model(reflect.New(child.ModelType).Interface()).Where(batch).Delete(reflect.New(child.ModelType).Interface()).Error
+		if err != nil {
+			return err
+		}
+		if err := stampDeletionGroup(db, child, batch, group); err != nil {
+			return err
+		}
+		if ids.Elem().Len() < batchSize {
+			return nil
+		}
+	}
+}
+
+// childRows returns the rows of rel's schema related to the parents rows.
+func childRows(db *gorm.DB, rel *schema.Relationship, parents *gorm.DB) *gorm.DB {
+	children := db.Model(reflect.New(rel.FieldSchema.ModelType).Interface())
+	for _, ref := range rel.References {
+		column := clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName}
+		if ref.PrimaryKey == nil {
+			// Polymorphic type column
+			children = children.Where(clause.Eq{Column: column, Value: ref.PrimaryValue})
+			continue
+		}
+		children = children.Where("? IN (?)", column, parents.Session(&gorm.Session{}).Select(ref.PrimaryKey.DBName))
+	}
+	return children
+}
+
+func toInterfaceSlice(v reflect.Value) []any {
+	values := make([]any, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values
+}
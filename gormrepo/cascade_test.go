@@ -0,0 +1,81 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type cascadeOrder struct {
+	ID            int64
+	DeletedAt     gorm.DeletedAt
+	DeletionGroup *string
+	Lines         []cascadeLine
+}
+
+type cascadeLine struct {
+	ID             int64
+	CascadeOrderID int64
+	DeletedAt      gorm.DeletedAt
+	DeletionGroup  *string
+}
+
+// updatesOf returns the tables and columns set by the UPDATE statements
+// run, in order, e.g. "cascade_lines.deleted_at".
+func updatesOf(fake *fakeDB) []string {
+	var updates []string
+	for _, stmt := range fake.ranLike("UPDATE ") {
+		table, rest, _ := strings.Cut(strings.TrimPrefix(stmt, "UPDATE "), " SET ")
+		column, _, _ := strings.Cut(rest, "=")
+		updates = append(updates, strings.Trim(table, `"`)+"."+strings.Trim(column, `"`))
+	}
+	return updates
+}
+
+func TestDeleteCascadeDeletesChildrenFirst(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	fake.queue([]string{"count"}, []driver.Value{int64(1)})
+	fake.queue([]string{"id"}, []driver.Value{int64(10)}, []driver.Value{int64(11)})
+
+	if err := New[cascadeOrder](db).DeleteCascade(7, CascadePlan{Associations: []string{"Lines"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"cascade_lines.deleted_at", "cascade_lines.deletion_group", "cascade_orders.deleted_at", "cascade_orders.deletion_group"}
+	if got := updatesOf(fake); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("updated %q, want %q", got, want)
+	}
+	// Arguments of the stamps: the group, then the ids
+	stamps := fake.ranLike(`SET "deletion_group"=?`)
+	lines := strings.Fields(stamps[0][strings.LastIndex(stamps[0], "[")+1 : len(stamps[0])-1])
+	order := strings.Fields(stamps[1][strings.LastIndex(stamps[1], "[")+1 : len(stamps[1])-1])
+	if strings.Join(lines[1:], " ") != "10 11" || strings.Join(order[1:], " ") != "7" || lines[0] != order[0] {
+		t.Errorf("stamped %q and %q, want lines 10 and 11 and order 7 in one group", stamps[0], stamps[1])
+	}
+	if got := fake.ran(); got[len(got)-1] != "COMMIT" {
+		t.Errorf("ran %q, want one transaction", got)
+	}
+}
+
+func TestRestoreCascadeRestoresTheDeletionGroup(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	fake.queue([]string{"deletion_group"}, []driver.Value{"g1"})
+
+	if err := New[cascadeOrder](db).RestoreCascade(7); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := fake.ranLike("UPDATE ")
+	if len(updates) != 2 {
+		t.Fatalf("ran %q, want the order then its lines restored", updates)
+	}
+	if !strings.HasPrefix(updates[0], `UPDATE "cascade_orders" SET "deleted_at"=?,"deletion_group"=? WHERE "cascade_orders"."id" = ?`) {
+		t.Errorf("restored the order with %q", updates[0])
+	}
+	if !strings.HasPrefix(updates[1], `UPDATE "cascade_lines" SET "deleted_at"=?,"deletion_group"=? WHERE "cascade_lines"."deletion_group" = ?`) ||
+		!strings.HasSuffix(updates[1], " g1]") {
+		t.Errorf("restored the lines with %q", updates[1])
+	}
+}
@@ -0,0 +1,136 @@
+package gormrepo
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type roleKey struct{}
+
+// ContextWithRole returns a context carrying the role queries run as.
+func ContextWithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
+
+// ColumnPolicy maps restricted columns to the roles allowed to read them.
+// Columns it doesn't mention are visible to every role.
+type ColumnPolicy map[string][]string
+
+// WithColumnPolicy leaves the columns the context's role may not see out of
+// every SELECT, including projections and explicit Select lists. Updates and
+// upserts leave those columns untouched, as entities loaded under the
+// policy hold zero values in them, and Refresh keeps their current values.
+func (r *GenericRepository[T]) WithColumnPolicy(policy ColumnPolicy) *GenericRepository[T] {
+	r.columnPolicy = policy
+	return r
+}
+
+// hiddenColumns returns the columns the role in ctx may not read.
+func (p ColumnPolicy) hiddenColumns(ctx context.Context, db *gorm.DB, model any) []string {
+	role := RoleFromContext(ctx)
+	s, err := parseSchema(db, model)
+
+	var hidden []string
+	for column, roles := range p {
+		if slices.Contains(roles, role) {
+			continue
+		}
+		if err == nil {
+			if field := s.LookUpField(column); field != nil && field.DBName != "" {
+				column = field.DBName
+			}
+		}
+		hidden = append(hidden, column)
+	}
+	return hidden
+}
+
+// redacted applies the column policy to a query.
+func (r *GenericRepository[T]) redacted(db *gorm.DB) *gorm.DB {
+	if r.columnPolicy == nil {
+		return db
+	}
+	hidden := r.columnPolicy.hiddenColumns(r.context(), db, new(T))
+	if len(hidden) == 0 {
+		return db
+	}
+
+	// Without an explicit selection the omitted columns are left out of the
+	// column list; when everything selected is hidden that list is used too.
+	// Selection expressions are kept as they are.
+	if len(db.Statement.Selects) == 0 {
+		return db.Omit(hidden...)
+	}
+	var visible []string
+	for _, sel := range db.Statement.Selects {
+		for _, part := range strings.Split(sel, ",") {
+			part = strings.TrimSpace(part)
+			if part != "" && !containsString(hidden, selectedColumn(part)) {
+				visible = append(visible, part)
+			}
+		}
+	}
+	return db.Select(visible).Omit(hidden...)
+}
+
+// writable keeps updates and upserts of db off the columns the context's role
+// may not read.
+func (r *GenericRepository[T]) writable(kind OperationKind, db *gorm.DB) *gorm.DB {
+	if r.columnPolicy == nil || (kind != OpUpdate && kind != OpUpsert) {
+		return db
+	}
+	hidden := r.columnPolicy.hiddenColumns(r.context(), db, new(T))
+	if len(hidden) == 0 {
+		return db
+	}
+	return db.Session(&gorm.Session{}).Omit(hidden...)
+}
+
+// keepHidden copies the fields the context's role may not read from entity
+// into fresh, which was loaded without them.
+func (r *GenericRepository[T]) keepHidden(s *schema.Schema, entity, fresh *T) error {
+	if r.columnPolicy == nil {
+		return nil
+	}
+	ctx := r.context()
+	from, to := reflect.ValueOf(entity).Elem(), reflect.ValueOf(fresh).Elem()
+	for _, column := range r.columnPolicy.hiddenColumns(ctx, r.db, entity) {
+		field := s.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		value, _ := field.ValueOf(ctx, from)
+		if err := field.Set(ctx, to, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectedColumn returns the column name a select expression starts with.
+func selectedColumn(expr string) string {
+	name := strings.Fields(expr)[0]
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"[]")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package gormrepo
+
+import (
+	"reflect"
+	"sync"
+)
+
+type computedField struct {
+	name string
+	fn   any
+}
+
+var (
+	computedMu     sync.RWMutex
+	computedFields = map[reflect.Type][]computedField{}
+)
+
+// RegisterComputedField registers fn to populate the derived, non-persisted
+// field name of T whenever a repository loads T entities. Registering the
+// same name again replaces the previous function.
+func RegisterComputedField[T any](name string, fn func(*T)) {
+	computedMu.Lock()
+	defer computedMu.Unlock()
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	fields := computedFields[typ]
+	for i := range fields {
+		if fields[i].name == name {
+			fields[i].fn = fn
+			return
+		}
+	}
+	computedFields[typ] = append(fields, computedField{name: name, fn: fn})
+}
+
+// computeFields runs the computed fields registered for T on loaded entities.
+func computeFields[T any](entities ...*T) {
+	computedMu.RLock()
+	fields := computedFields[reflect.TypeOf((*T)(nil)).Elem()]
+	computedMu.RUnlock()
+
+	for _, field := range fields {
+		fn := field.fn.(func(*T))
+		for _, entity := range entities {
+			if entity != nil {
+				fn(entity)
+			}
+		}
+	}
+}
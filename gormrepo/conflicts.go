@@ -0,0 +1,209 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// conflictBatchSize bounds the tuples looked up per query, keeping the
+// statement under the dialects' parameter limits.
+const conflictBatchSize = 500
+
+// Conflict is an incoming entity whose unique columns match a stored row.
+type Conflict[T any] struct {
+	Index    int // Position of the incoming entity
+	Incoming *T
+	Existing T
+}
+
+// FindConflicts returns the entities that collide with existing rows on
+// uniqueColumns, so an import can report them before writing anything.
+// Entities with a NULL unique column never conflict. The database compares
+// the values, so e.g. a case-insensitive collation matches "A" with "a".
+func (r *GenericRepository[T]) FindConflicts(entities *[]T, uniqueColumns ...string) ([]Conflict[T], error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if len(uniqueColumns) == 0 {
+		return nil, fmt.Errorf("conflict check needs at least one unique column")
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(uniqueColumns))
+	columns := make([]string, len(uniqueColumns))
+	for i, name := range uniqueColumns {
+		field := lookUpFieldFold(s, name)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, name)
+		}
+		fields[i], columns[i] = field, field.DBName
+	}
+
+	ctx := r.context()
+	incoming := map[string][]int{}
+	var tuples [][]any
+	for i := range *entities {
+		entity := reflect.ValueOf(&(*entities)[i]).Elem()
+		tuple := make([]any, len(fields))
+		for j, field := range fields {
+			value, _ := field.ValueOf(ctx, entity)
+			if value = columnValue(value); value == nil {
+				tuple = nil
+				break
+			}
+			tuple[j] = value
+		}
+		if tuple == nil {
+			continue
+		}
+		key := tupleKey(tuple)
+		if _, ok := incoming[key]; !ok {
+			tuples = append(tuples, tuple)
+		}
+		incoming[key] = append(incoming[key], i)
+	}
+
+	// The database compares the values, with its collations and types: each
+	// batch first finds which stored rows equal which tuples, unredacted,
+	// then loads those rows as any query would
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return nil, gorm.ErrPrimaryKeyRequired
+	}
+	pkColumn := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	size := max(1, min(conflictBatchSize, 1000/len(columns)))
+
+	var conflicts []Conflict[T]
+	for start := 0; start < len(tuples); start += size {
+		batch := tuples[start:min(start+size, len(tuples))]
+
+		var condition clause.Expression
+		switch r.db.Dialector.Name() {
+		case "postgres", "mysql", "sqlite":
+			condition = tupleIn(columns, batch)
+		default:
+			condition = tupleOr(columns, batch)
+		}
+
+		selects := []string{"?"}
+		vars := []any{pkColumn}
+		for _, tuple := range batch {
+			eqs := make([]clause.Expression, len(columns))
+			for j, column := range columns {
+				eqs[j] = clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: tuple[j]}
+			}
+			selects = append(selects, "CASE WHEN ? THEN 1 ELSE 0 END")
+			vars = append(vars, clause.And(eqs...))
+		}
+
+		matches := make([][]string, len(batch))
+		var ids []any
+		err := r.run(OpQuery, nil, func() error {
+			rows, err := r.readDB(r.db.Session(&gorm.Session{}).Model(new(T)).Where(condition)).
+				Select(strings.Join(selects, ", "), vars...).
+				Rows()
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			var id any
+			flags := make([]int64, len(batch))
+			dest := []any{&id}
+			for j := range flags {
+				dest = append(dest, &flags[j])
+			}
+			for rows.Next() {
+				if err := rows.Scan(dest...); err != nil {
+					return err
+				}
+				ids = append(ids, id)
+				for j, flag := range flags {
+					if flag == 1 {
+						matches[j] = append(matches[j], tupleKey([]any{id}))
+					}
+				}
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		var existing []T
+		err = r.run(OpQuery, nil, func() error {
+			query := r.db.Session(&gorm.Session{}).Model(new(T)).Where(clause.IN{Column: pkColumn, Values: ids})
+			return r.redacted(r.readDB(query)).Find(&existing).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.afterLoad(pointersTo(existing)...)
+		byID := map[string]T{}
+		for _, row := range existing {
+			id, _ := pk.ValueOf(ctx, reflect.ValueOf(&row).Elem())
+			byID[tupleKey([]any{columnValue(id)})] = row
+		}
+
+		for j, tuple := range batch {
+			for _, id := range matches[j] {
+				row, ok := byID[id]
+				if !ok {
+					continue
+				}
+				for _, i := range incoming[tupleKey(tuple)] {
+					conflicts = append(conflicts, Conflict[T]{Index: i, Incoming: &(*entities)[i], Existing: row})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(conflicts, func(a, b Conflict[T]) int { return a.Index - b.Index })
+	return conflicts, nil
+}
+
+// columnValue returns the value stored for a field value, nil for NULL.
+func columnValue(value any) any {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v := reflect.ValueOf(valuer); v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil
+		}
+		value, _ = valuer.Value()
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// tupleKey identifies a tuple of column values independently of the Go
+// types the driver scans them into.
+func tupleKey(tuple []any) string {
+	parts := make([]string, len(tuple))
+	for i, value := range tuple {
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,158 @@
+package gormrepo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrConnectionOrder = errors.New("connections are ordered by primary key")
+)
+
+// Connection is a Relay cursor connection of entities.
+type Connection[T any] struct {
+	Edges    []Edge[T]
+	PageInfo PageInfo
+}
+
+type Edge[T any] struct {
+	Node   T
+	Cursor string
+}
+
+type PageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     string
+	EndCursor       string
+}
+
+// Connection pages through the chain by primary key following the Relay
+// cursor connections spec: first/after read forward, last/before read
+// backwards. Zero and empty arguments are unset; without first or last a
+// page of DefaultPageSize is read forward. As cursors hold the primary key
+// alone, WithDefaultOrder doesn't apply and chains setting an order fail
+// with ErrConnectionOrder.
+func (r *GenericRepository[T]) Connection(first int, after string, last int, before string) (*Connection[T], error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if first < 0 || last < 0 {
+		return nil, fmt.Errorf("first and last cannot be negative")
+	}
+	if first > 0 && last > 0 {
+		return nil, fmt.Errorf("first and last cannot be combined")
+	}
+	if hasOrder(r.db) {
+		return nil, ErrConnectionOrder
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if len(s.PrimaryFields) != 1 {
+		return nil, fmt.Errorf("connections need a single primary key, %s has %d", s.Name, len(s.PrimaryFields))
+	}
+	pk := s.PrimaryFields[0]
+	column := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+
+	backward := last > 0
+	size := first
+	if backward {
+		size = last
+	}
+	if size == 0 {
+		size = DefaultPageSize
+	}
+	size = min(size, MaxPageSize)
+
+	db := r.db
+	if after != "" {
+		value, err := decodeCursor(after, pk.FieldType)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(clause.Gt{Column: column, Value: value})
+	}
+	if before != "" {
+		value, err := decodeCursor(before, pk.FieldType)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(clause.Lt{Column: column, Value: value})
+	}
+
+	// Fetch one extra row to know whether more exist past the page
+	var entities []T
+	err = r.run(OpQuery, nil, func() error {
+		query := db.Order(clause.OrderByColumn{Column: column, Desc: backward}).Limit(size + 1)
+		return r.redacted(r.readDB(query)).Find(&entities).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(entities) > size
+	if more {
+		entities = entities[:size]
+	}
+	if backward {
+		slices.Reverse(entities)
+	}
+	r.afterLoad(pointersTo(entities)...)
+
+	conn := &Connection[T]{Edges: make([]Edge[T], 0, len(entities))}
+	for i := range entities {
+		id, _ := pk.ValueOf(r.context(), reflect.ValueOf(&entities[i]).Elem())
+		cursor, err := encodeCursor(id)
+		if err != nil {
+			return nil, err
+		}
+		conn.Edges = append(conn.Edges, Edge[T]{Node: entities[i], Cursor: cursor})
+	}
+
+	if backward {
+		conn.PageInfo.HasPreviousPage = more
+		conn.PageInfo.HasNextPage = before != ""
+	} else {
+		conn.PageInfo.HasNextPage = more
+		conn.PageInfo.HasPreviousPage = after != ""
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = conn.Edges[len(conn.Edges)-1].Cursor
+	}
+
+	r.currentSlice = &entities
+	return conn, nil
+}
+
+func encodeCursor(key interface{}) (string, error) {
+	payload, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// decodeCursor reads the key held by cursor as a value of keyType.
+func decodeCursor(cursor string, keyType reflect.Type) (interface{}, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	key := reflect.New(keyType)
+	if err := json.Unmarshal(payload, key.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return key.Elem().Interface(), nil
+}
@@ -0,0 +1,145 @@
+package gormrepo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Participant is one database taking part in a coordinated operation.
+type Participant struct {
+	Name       string
+	DB         *gorm.DB
+	Operation  func(tx *gorm.DB) error
+	Compensate func(db *gorm.DB) error // Undoes a committed Operation when falling back to a saga
+}
+
+// Coordinator runs operations against several databases so that they either all
+// apply or none do. When every participant is Postgres with
+// max_prepared_transactions above zero it uses prepared transactions (2PC);
+// otherwise it commits each participant in turn and runs the compensation
+// hooks of the committed ones if a later participant fails.
+type Coordinator struct {
+	participants []Participant
+}
+
+func NewCoordinator() *Coordinator {
+	return &Coordinator{}
+}
+
+func (c *Coordinator) Add(name string, db *gorm.DB, operation func(tx *gorm.DB) error, compensate func(db *gorm.DB) error) *Coordinator {
+	c.participants = append(c.participants, Participant{
+		Name:       name,
+		DB:         db,
+		Operation:  operation,
+		Compensate: compensate,
+	})
+	return c
+}
+
+func (c *Coordinator) Run(ctx context.Context) error {
+	if len(c.participants) == 0 {
+		return nil
+	}
+
+	if c.supportsPreparedTransactions(ctx) {
+		return c.runTwoPhase(ctx)
+	}
+	return c.runSaga(ctx)
+}
+
+// supportsPreparedTransactions reports whether every participant is Postgres
+// allowing prepared transactions, which are disabled by default.
+func (c *Coordinator) supportsPreparedTransactions(ctx context.Context) bool {
+	for _, p := range c.participants {
+		if p.DB.Dialector.Name() != "postgres" {
+			return false
+		}
+	}
+	for _, p := range c.participants {
+		var limit []int
+		err := p.DB.WithContext(ctx).Raw("SELECT current_setting('max_prepared_transactions')::int").Scan(&limit).Error
+		if err != nil || len(limit) == 0 || limit[0] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Coordinator) runTwoPhase(ctx context.Context) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	gid := "gormrepo_" + hex.EncodeToString(suffix)
+	// A prepared transaction must be resolved even when the caller gives up
+	resolveCtx := context.WithoutCancel(ctx)
+	txs := make([]*gorm.DB, 0, len(c.participants))
+	prepared := 0
+
+	abort := func(cause error) error {
+		var errs []error
+		for i := 0; i < prepared; i++ {
+			stmt := fmt.Sprintf("ROLLBACK PREPARED '%s_%d'", gid, i)
+			if err := c.participants[i].DB.WithContext(resolveCtx).Exec(stmt).Error; err != nil {
+				errs = append(errs, fmt.Errorf("rollback prepared %s: %w", c.participants[i].Name, err))
+			}
+		}
+		for _, tx := range txs[prepared:] {
+			tx.Rollback()
+		}
+		return errors.Join(append([]error{cause}, errs...)...)
+	}
+
+	for _, p := range c.participants {
+		tx := p.DB.WithContext(ctx).Begin()
+		if tx.Error != nil {
+			return abort(fmt.Errorf("begin %s: %w", p.Name, tx.Error))
+		}
+		txs = append(txs, tx)
+
+		if err := p.Operation(tx); err != nil {
+			return abort(fmt.Errorf("participant %s failed: %w", p.Name, err))
+		}
+	}
+
+	for i, tx := range txs {
+		stmt := fmt.Sprintf("PREPARE TRANSACTION '%s_%d'", gid, i)
+		if err := tx.Exec(stmt).Error; err != nil {
+			return abort(fmt.Errorf("prepare %s: %w", c.participants[i].Name, err))
+		}
+		// The session left the transaction on PREPARE; this only releases the connection
+		tx.Commit()
+		prepared++
+	}
+
+	var errs []error
+	for i, p := range c.participants {
+		stmt := fmt.Sprintf("COMMIT PREPARED '%s_%d'", gid, i)
+		if err := p.DB.WithContext(resolveCtx).Exec(stmt).Error; err != nil {
+			errs = append(errs, fmt.Errorf("commit prepared %s (transaction %s_%d left in doubt): %w", p.Name, gid, i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Coordinator) runSaga(ctx context.Context) error {
+	saga := NewSaga()
+	for _, p := range c.participants {
+		var compensate func(ctx context.Context) error
+		if p.Compensate != nil {
+			compensate = func(ctx context.Context) error {
+				return p.Compensate(p.DB.WithContext(ctx))
+			}
+		}
+		saga.Step(p.Name, func(ctx context.Context) error {
+			return p.DB.WithContext(ctx).Transaction(p.Operation)
+		}, compensate)
+	}
+	return saga.Run(ctx)
+}
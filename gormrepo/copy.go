@@ -0,0 +1,170 @@
+package gormrepo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type CopyOptions struct {
+	// Associations are copied along with each row, e.g. "Orders.Items".
+	Associations []string
+	// RemapKeys lets the target assign new primary keys to the rows and to
+	// their has-one and has-many associations, whose foreign keys follow.
+	// Belongs-to and many-to-many rows are shared and keep their keys.
+	RemapKeys bool
+	// Overwrite updates rows the target already has instead of failing.
+	Overwrite bool
+	BatchSize int // Defaults to 500
+}
+
+type CopyResult struct {
+	Copied int64
+	Keys   map[any]any // Source to target primary keys, with RemapKeys
+}
+
+// CopyTo copies the rows matching filters into target, another database
+// with the same schema, one batch at a time, e.g. to refresh staging data
+// or move a tenant.
+func (r *GenericRepository[T]) CopyTo(target *gorm.DB, filters map[string]any, opts CopyOptions) (*CopyResult, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return nil, gorm.ErrPrimaryKeyRequired
+	}
+	owned, err := ownedRelations(s, opts.Associations)
+	if err != nil {
+		return nil, err
+	}
+
+	source := r.whereFilters(r.db.Model(new(T)), filters)
+	for _, association := range opts.Associations {
+		source = source.Preload(association)
+	}
+
+	ctx := r.context()
+	target = target.WithContext(ctx)
+	if opts.Overwrite {
+		target = target.Session(&gorm.Session{FullSaveAssociations: true}).Clauses(clause.OnConflict{UpdateAll: true})
+	}
+	target = target.Session(&gorm.Session{})
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = conflictBatchSize
+	}
+
+	result := &CopyResult{}
+	if opts.RemapKeys {
+		result.Keys = map[any]any{}
+	}
+	var batch []T
+	err = r.run(OpQuery, nil, func() error {
+		return r.readDB(source).FindInBatches(&batch, batchSize, func(*gorm.DB, int) error {
+			var keys []any
+			if opts.RemapKeys {
+				for i := range batch {
+					row := reflect.ValueOf(&batch[i]).Elem()
+					key, _ := pk.ValueOf(ctx, row)
+					keys = append(keys, key)
+					if err := clearKeys(ctx, s, row, owned); err != nil {
+						return err
+					}
+				}
+			}
+
+			create := func(tx *gorm.DB) error {
+				return tx.Create(&batch).Error
+			}
+			if !opts.RemapKeys && target.Dialector.Name() == "sqlserver" && identityKey(s) {
+				if err := identityInsert(target, s.Table, create); err != nil {
+					return err
+				}
+			} else if err := create(target); err != nil {
+				return err
+			}
+			result.Copied += int64(len(batch))
+
+			// FindInBatches continues after the last source key
+			for i, key := range keys {
+				row := reflect.ValueOf(&batch[i]).Elem()
+				result.Keys[key], _ = pk.ValueOf(ctx, row)
+				if err := pk.Set(ctx, row, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	})
+	return result, err
+}
+
+// ownedRelations resolves the has-one and has-many relationships along
+// association paths, the ones whose rows get new keys when copied.
+func ownedRelations(s *schema.Schema, paths []string) ([][]*schema.Relationship, error) {
+	var owned [][]*schema.Relationship
+	for _, path := range paths {
+		current := s
+		var chain []*schema.Relationship
+		for _, name := range strings.Split(path, ".") {
+			rel, ok := current.Relationships.Relations[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s has no association %s", ErrUnknownField, current.Name, name)
+			}
+			if rel.Type != schema.HasOne && rel.Type != schema.HasMany {
+				break
+			}
+			chain = append(chain, rel)
+			current = rel.FieldSchema
+		}
+		if len(chain) > 0 {
+			owned = append(owned, chain)
+		}
+	}
+	return owned, nil
+}
+
+// clearKeys zeroes the primary keys of row and of its rows along chains.
+func clearKeys(ctx context.Context, s *schema.Schema, row reflect.Value, chains [][]*schema.Relationship) error {
+	if !row.IsValid() {
+		return nil
+	}
+	for _, field := range s.PrimaryFields {
+		if err := field.Set(ctx, row, reflect.Zero(field.FieldType).Interface()); err != nil {
+			return err
+		}
+	}
+	for _, chain := range chains {
+		rel := chain[0]
+		children := reflect.Indirect(rel.Field.ReflectValueOf(ctx, row))
+		var rest [][]*schema.Relationship
+		if len(chain) > 1 {
+			rest = [][]*schema.Relationship{chain[1:]}
+		}
+
+		switch children.Kind() {
+		case reflect.Slice:
+			for i := 0; i < children.Len(); i++ {
+				if err := clearKeys(ctx, rel.FieldSchema, reflect.Indirect(children.Index(i)), rest); err != nil {
+					return err
+				}
+			}
+		case reflect.Struct:
+			if err := clearKeys(ctx, rel.FieldSchema, children, rest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package gormrepo
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Counter struct {
+	Name  string `gorm:"primaryKey;size:191"`
+	Value int64
+}
+
+func (Counter) TableName() string {
+	return "counters"
+}
+
+// NextCounter increments the named counter and returns its new value. The
+// counter row stays locked until the surrounding transaction ends, so calling
+// it inside Transaction gives gapless per-name sequences (invoice numbers,
+// per-tenant numbering): a rollback also rolls back the increment.
+func (r *GenericRepository[T]) NextCounter(name string) (int64, error) {
+	var value int64
+	db := r.db.Session(&gorm.Session{NewDB: true}).Table(Counter{}.TableName())
+	err := r.runOn(db, OpUpdate, nil, nil, func() error {
+
+		caps := DetectCapabilities(db)
+		switch {
+		case caps.Dialect != "mysql" && caps.Returning && caps.Upsert:
+			return db.Raw(
+				"INSERT INTO ? (name, value) VALUES (?, 1) ON CONFLICT (name) DO UPDATE SET value = ?.value + 1 RETURNING value",
+				clause.Table{Name: Counter{}.TableName()}, name, clause.Table{Name: Counter{}.TableName()},
+			).Scan(&value).Error
+		case caps.Dialect == "mysql":
+			// LAST_INSERT_ID is per connection; the transaction pins one
+			return db.Transaction(func(tx *gorm.DB) error {
+				err := tx.Exec(
+					"INSERT INTO ? (name, value) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE value = LAST_INSERT_ID(value + 1)",
+					clause.Table{Name: Counter{}.TableName()}, name,
+				).Error
+				if err != nil {
+					return err
+				}
+				return tx.Raw("SELECT LAST_INSERT_ID()").Scan(&value).Error
+			})
+		default:
+			return db.Transaction(func(tx *gorm.DB) error {
+				increment := func() (int64, error) {
+					res := tx.Model(&Counter{}).Where("name = ?", name).Update("value", gorm.Expr("value + 1"))
+					return res.RowsAffected, res.Error
+				}
+				rows, err := increment()
+				if err != nil {
+					return err
+				}
+				if rows == 0 {
+					// Callers using the name for the first time may race to
+					// create its row; whichever loses increments the winner's
+					err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Counter{Name: name}).Error
+					if err != nil {
+						return err
+					}
+					if _, err := increment(); err != nil {
+						return err
+					}
+				}
+				return tx.Model(&Counter{}).Where("name = ?", name).Pluck("value", &value).Error
+			})
+		}
+	})
+	return value, err
+}
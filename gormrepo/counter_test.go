@@ -0,0 +1,35 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestNextCounterCreatesNewNames(t *testing.T) {
+	db, fake := newTestDB(t, "sqlite", "3.31.1")
+	fake.unaffected = "value + 1"
+	fake.queue([]string{"value"}, []driver.Value{int64(1)})
+
+	value, err := New[testItem](db).NextCounter("invoices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 1 {
+		t.Errorf("got %d, want 1", value)
+	}
+
+	// A racing caller creating the row first is incremented, not overwritten
+	want := []string{
+		"BEGIN",
+		`UPDATE "counters" SET "value"=value + 1 WHERE name = ? [invoices]`,
+		`INSERT INTO "counters" ("name","value") VALUES (?,?) ON CONFLICT DO NOTHING [invoices 0]`,
+		`UPDATE "counters" SET "value"=value + 1 WHERE name = ? [invoices]`,
+		`SELECT "value" FROM "counters" WHERE name = ? [invoices]`,
+		"COMMIT",
+	}
+	got := fake.ran()[1:]
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
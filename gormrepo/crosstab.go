@@ -0,0 +1,151 @@
+package gormrepo
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PivotQuery builds a crosstab report: one row per value of the row fields
+// and one column per value of the pivot field, holding an aggregate.
+type PivotQuery[T any] struct {
+	repo      *GenericRepository[T]
+	column    string
+	rows      []string
+	values    []any
+	aggregate string
+	field     string
+}
+
+// Pivot starts a crosstab of the chain grouped by rows with a column for
+// each value of column, e.g. Pivot("status", "region").Sum("amount"). The
+// aggregate defaults to Count.
+func (r *GenericRepository[T]) Pivot(column string, rows ...string) *PivotQuery[T] {
+	return &PivotQuery[T]{repo: r, column: column, rows: rows, aggregate: "COUNT"}
+}
+
+// Values fixes the pivot columns and their order; nil gets a NULL column.
+// Without it every distinct non-NULL value of the pivot field becomes a
+// column.
+func (q *PivotQuery[T]) Values(values ...any) *PivotQuery[T] {
+	q.values = values
+	return q
+}
+
+func (q *PivotQuery[T]) Count() *PivotQuery[T] {
+	return q.aggregateOf("COUNT", "")
+}
+
+func (q *PivotQuery[T]) Sum(field string) *PivotQuery[T] {
+	return q.aggregateOf("SUM", field)
+}
+
+func (q *PivotQuery[T]) Avg(field string) *PivotQuery[T] {
+	return q.aggregateOf("AVG", field)
+}
+
+func (q *PivotQuery[T]) Min(field string) *PivotQuery[T] {
+	return q.aggregateOf("MIN", field)
+}
+
+func (q *PivotQuery[T]) Max(field string) *PivotQuery[T] {
+	return q.aggregateOf("MAX", field)
+}
+
+func (q *PivotQuery[T]) aggregateOf(fn, field string) *PivotQuery[T] {
+	q.aggregate, q.field = fn, field
+	return q
+}
+
+// Maps runs the report and returns each row as a map from row field and
+// pivot value, formatted with fmt, to its value.
+func (q *PivotQuery[T]) Maps() ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	if err := q.Scan(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Scan runs the report into dest, a slice of maps or of structs whose
+// columns are the row fields and the pivot values.
+func (q *PivotQuery[T]) Scan(dest interface{}) error {
+	r := q.repo
+	if r.lastError != nil {
+		return r.lastError
+	}
+
+	fields := append([]string{q.column}, q.rows...)
+	if q.field != "" {
+		fields = append(fields, q.field)
+	}
+	columns, err := r.columnsOf(fields)
+	if err != nil {
+		return err
+	}
+	column, rows := columns[0], columns[1:len(q.rows)+1]
+
+	values := q.values
+	if values == nil {
+		// NULL is no value of the pivot field, so it gets no column
+		query := r.db.Session(&gorm.Session{}).Model(new(T)).
+			Where(clause.Expr{SQL: "? IS NOT NULL", Vars: []interface{}{clause.Column{Name: column}}}).
+			Distinct(column).Order(column)
+		err := r.run(OpQuery, nil, func() error {
+			return r.readDB(query).Pluck(column, &values).Error
+		})
+		if err != nil {
+			return err
+		}
+		// Some drivers return text as bytes, which would not print as a name
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+	}
+
+	measure := "1"
+	if q.field != "" {
+		measure = "?"
+	}
+	selects := make([]string, 0, len(rows)+len(values))
+	vars := make([]interface{}, 0, len(rows)+len(values)*3)
+	groups := make([]clause.Column, len(rows))
+	for i, row := range rows {
+		groups[i] = clause.Column{Name: row}
+		selects = append(selects, "?")
+		vars = append(vars, groups[i])
+	}
+	for _, value := range values {
+		if value == nil {
+			selects = append(selects, fmt.Sprintf("%s(CASE WHEN ? IS NULL THEN %s END) AS ?", q.aggregate, measure))
+			vars = append(vars, clause.Column{Name: column})
+		} else {
+			selects = append(selects, fmt.Sprintf("%s(CASE WHEN ? = ? THEN %s END) AS ?", q.aggregate, measure))
+			vars = append(vars, clause.Column{Name: column}, value)
+		}
+		if q.field != "" {
+			vars = append(vars, clause.Column{Name: columns[len(columns)-1]})
+		}
+		name := fmt.Sprint(value)
+		if value == nil {
+			name = "NULL"
+		}
+		vars = append(vars, clause.Column{Name: name})
+	}
+
+	db := r.db.Model(new(T)).Select(strings.Join(selects, ", "), vars...)
+	if len(groups) > 0 {
+		order := make([]clause.OrderByColumn, len(groups))
+		for i, group := range groups {
+			order[i] = clause.OrderByColumn{Column: group}
+		}
+		db = db.Clauses(clause.GroupBy{Columns: groups}).Order(clause.OrderBy{Columns: order})
+	}
+	return r.run(OpQuery, nil, func() error {
+		return r.readDB(db).Scan(dest).Error
+	})
+}
@@ -0,0 +1,157 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm/clause"
+)
+
+type converterKey struct {
+	from, to reflect.Type
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[converterKey]func(reflect.Value) (reflect.Value, error){}
+)
+
+// RegisterConverter makes DTO mapping convert S fields to D fields with fn,
+// e.g. a currency value object to its minor units.
+func RegisterConverter[S, D any](fn func(S) (D, error)) {
+	from := reflect.TypeOf((*S)(nil)).Elem()
+	to := reflect.TypeOf((*D)(nil)).Elem()
+
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[converterKey{from, to}] = func(v reflect.Value) (reflect.Value, error) {
+		d, err := fn(v.Interface().(S))
+		return reflect.ValueOf(&d).Elem(), err
+	}
+}
+
+// Decimal types such as shopspring/decimal are recognized by these methods.
+type (
+	decimalString interface{ String() string }
+	decimalFloat  interface{ InexactFloat64() float64 }
+	exactFloat    interface {
+		Float64() (float64, bool)
+	}
+)
+
+// convertField sets dst from src with a registered converter and reports
+// whether there was one.
+func convertField(src, dst reflect.Value) (bool, error) {
+	convertersMu.RLock()
+	fn, ok := converters[converterKey{src.Type(), dst.Type()}]
+	convertersMu.RUnlock()
+	if ok {
+		v, err := fn(src)
+		if err != nil {
+			return true, err
+		}
+		dst.Set(v)
+		return true, nil
+	}
+	return false, nil
+}
+
+// decimalField sets string and float fields from decimal types.
+func decimalField(src, dst reflect.Value) bool {
+	if !src.CanInterface() {
+		return false
+	}
+	switch dst.Kind() {
+	case reflect.String:
+		if s, ok := src.Interface().(decimalString); ok {
+			dst.SetString(s.String())
+			return true
+		}
+	case reflect.Float32, reflect.Float64:
+		switch f := src.Interface().(type) {
+		case decimalFloat:
+			dst.SetFloat(f.InexactFloat64())
+			return true
+		case exactFloat:
+			v, _ := f.Float64()
+			dst.SetFloat(v)
+			return true
+		}
+	}
+	return false
+}
+
+// WhereAmountGt and the following helpers compare a decimal column with
+// amount without going through floating point. amount can be a decimal type,
+// a string or a number.
+func (r *GenericRepository[T]) WhereAmountGt(column string, amount any) *GenericRepository[T] {
+	return r.whereAmount(column, ">", amount)
+}
+
+func (r *GenericRepository[T]) WhereAmountGte(column string, amount any) *GenericRepository[T] {
+	return r.whereAmount(column, ">=", amount)
+}
+
+func (r *GenericRepository[T]) WhereAmountLt(column string, amount any) *GenericRepository[T] {
+	return r.whereAmount(column, "<", amount)
+}
+
+func (r *GenericRepository[T]) WhereAmountLte(column string, amount any) *GenericRepository[T] {
+	return r.whereAmount(column, "<=", amount)
+}
+
+func (r *GenericRepository[T]) whereAmount(column, op string, amount any) *GenericRepository[T] {
+	if r.pairedAmount(column) {
+		r.lastError = fmt.Errorf("%w: %s", ErrUnpairedAmount, column)
+		return r
+	}
+	return r.compareAmount(column, op, amount)
+}
+
+func (r *GenericRepository[T]) compareAmount(column, op string, amount any) *GenericRepository[T] {
+	value, err := decimalText(amount)
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	param := "?"
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		param = "CAST(? AS NUMERIC)"
+	case "mysql":
+		param = "CAST(? AS DECIMAL(65,30))"
+	}
+
+	r.db = r.db.Where(clause.Expr{
+		SQL:  fmt.Sprintf("? %s %s", op, param),
+		Vars: []interface{}{clause.Column{Name: column}, value},
+	})
+	return r
+}
+
+// decimalText returns the exact decimal representation of amount.
+func decimalText(amount any) (string, error) {
+	switch v := amount.(type) {
+	case string:
+		return v, nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		return decimalText(value)
+	case decimalString:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("unsupported amount type %T", amount)
+}
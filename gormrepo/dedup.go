@@ -0,0 +1,195 @@
+package gormrepo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// KeepStrategy selects the row of a duplicate group DeduplicateKeeping keeps.
+type KeepStrategy int
+
+const (
+	KeepOldest KeepStrategy = iota
+	KeepNewest
+)
+
+// DuplicateGroup is a set of rows sharing the same values on the compared
+// columns. IDs are ordered from oldest to newest.
+type DuplicateGroup struct {
+	Values map[string]any
+	Count  int64
+	IDs    []any
+}
+
+// FindDuplicates returns the groups of rows matching the chain conditions
+// that share the same values on columns. As in SQL comparisons, NULLs are
+// not equal to each other, so rows with a NULL in columns are never
+// duplicates. Rows are ordered by their auto-create time when T has one, by
+// primary key otherwise.
+func (r *GenericRepository[T]) FindDuplicates(columns ...string) ([]DuplicateGroup, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("duplicate check needs at least one column")
+	}
+	dbNames, err := r.columnsOf(columns)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, gorm.ErrPrimaryKeyRequired
+	}
+
+	groupColumns := make([]clause.Column, len(dbNames))
+	notNull := make([]clause.Expression, len(dbNames))
+	for i, name := range dbNames {
+		groupColumns[i] = clause.Column{Name: name}
+		notNull[i] = clause.Neq{Column: groupColumns[i], Value: nil}
+	}
+
+	var counts []map[string]any
+	err = r.run(OpQuery, nil, func() error {
+		return r.readDB(r.db.Model(new(T))).
+			Select(append(slices.Clone(dbNames), "COUNT(*) AS duplicate_count__")).
+			Where(clause.And(notNull...)).
+			Clauses(clause.GroupBy{
+				Columns: groupColumns,
+				Having:  []clause.Expression{clause.Expr{SQL: "COUNT(*) > 1"}},
+			}).
+			Find(&counts).Error
+	})
+	if err != nil || len(counts) == 0 {
+		return nil, err
+	}
+
+	groups := make([]DuplicateGroup, len(counts))
+	index := map[string]int{}
+	tuples := make([][]any, len(counts))
+	for i, row := range counts {
+		groups[i].Values = map[string]any{}
+		tuples[i] = make([]any, len(dbNames))
+		for j, name := range dbNames {
+			groups[i].Values[name] = row[name]
+			tuples[i][j] = row[name]
+		}
+		groups[i].Count = toInt64(row["duplicate_count__"])
+		index[tupleKey(tuples[i])] = i
+	}
+
+	pk := s.PrioritizedPrimaryField.DBName
+	var members []map[string]any
+	for start := 0; start < len(tuples); start += conflictBatchSize {
+		batch := tuples[start:min(start+conflictBatchSize, len(tuples))]
+		condition := tupleOr(dbNames, batch)
+		if name := r.db.Dialector.Name(); name == "postgres" || name == "mysql" || name == "sqlite" {
+			condition = tupleIn(dbNames, batch)
+		}
+
+		var rows []map[string]any
+		err := r.run(OpQuery, nil, func() error {
+			return r.readDB(r.db.Model(new(T))).
+				Select(append([]string{pk}, dbNames...)).
+				Where(condition).
+				Order(duplicateOrder(s)).
+				Find(&rows).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, rows...)
+	}
+
+	for _, row := range members {
+		tuple := make([]any, len(dbNames))
+		for j, name := range dbNames {
+			tuple[j] = row[name]
+		}
+		if i, ok := index[tupleKey(tuple)]; ok {
+			groups[i].IDs = append(groups[i].IDs, row[pk])
+		}
+	}
+	return groups, nil
+}
+
+// DeduplicateKeeping deletes every row of each duplicate group on columns
+// except the oldest or newest one, and returns the number of rows deleted.
+func (r *GenericRepository[T]) DeduplicateKeeping(strategy KeepStrategy, columns ...string) (int64, error) {
+	groups, err := r.FindDuplicates(columns...)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []any
+	for _, group := range groups {
+		// Rows of the group may have changed since it was counted
+		if len(group.IDs) < 2 {
+			continue
+		}
+		if strategy == KeepNewest {
+			ids = append(ids, group.IDs[:len(group.IDs)-1]...)
+		} else {
+			ids = append(ids, group.IDs[1:]...)
+		}
+	}
+
+	var deleted int64
+	for start := 0; start < len(ids); start += conflictBatchSize {
+		batch := ids[start:min(start+conflictBatchSize, len(ids))]
+		err := r.write(OpDelete, nil, func(db *gorm.DB) error {
+			res := db.Delete(new(T), batch)
+			deleted += res.RowsAffected
+			return res.Error
+		})
+		if err == nil {
+			err = r.onCommit(func() error {
+				for _, id := range batch {
+					r.uncacheID(id)
+				}
+				return nil
+			})
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// duplicateOrder orders rows from oldest to newest.
+func duplicateOrder(s *schema.Schema) clause.OrderBy {
+	var order clause.OrderBy
+	for _, field := range s.Fields {
+		if field.AutoCreateTime > 0 && field.DBName != "" {
+			order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: field.DBName}})
+			break
+		}
+	}
+	order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}})
+	return order
+}
+
+// toInt64 converts a count scanned into a map, whose type depends on the
+// driver.
+func toInt64(value any) int64 {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float())
+	}
+	n, _ := strconv.ParseInt(tupleKey([]any{value}), 10, 64)
+	return n
+}
@@ -0,0 +1,126 @@
+package gormrepo
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Capabilities are the SQL features of a database that differ between
+// dialects and server versions.
+type Capabilities struct {
+	Dialect    string
+	Version    string // Empty when the server version couldn't be read
+	Returning  bool   // INSERT ... RETURNING
+	Upsert     bool   // INSERT ... ON CONFLICT or ON DUPLICATE KEY UPDATE
+	RowLocking bool   // SELECT ... FOR UPDATE
+	SkipLocked bool   // SELECT ... FOR UPDATE SKIP LOCKED
+	ILike      bool
+	// OFFSET ... FETCH NEXT, which gorm's SQL Server and Oracle drivers
+	// paginate with
+	OffsetFetch bool
+}
+
+var capabilities sync.Map // *sql.DB to Capabilities
+
+// DetectCapabilities returns the capabilities of the database behind db,
+// reading its server version once per connection pool. When the version
+// can't be read, the capabilities of current releases of the dialect are
+// assumed.
+func DetectCapabilities(db *gorm.DB) Capabilities {
+	pool, err := db.DB()
+	if err == nil {
+		if caps, ok := capabilities.Load(pool); ok {
+			return caps.(Capabilities)
+		}
+	}
+
+	caps := Capabilities{Dialect: db.Dialector.Name()}
+	var query string
+	switch caps.Dialect {
+	case "postgres":
+		query = "SHOW server_version"
+	case "mysql":
+		query = "SELECT VERSION()"
+	case "sqlite":
+		query = "SELECT sqlite_version()"
+	case "sqlserver":
+		query = "SELECT CAST(SERVERPROPERTY('ProductVersion') AS VARCHAR(32))"
+	case "oracle":
+		query = "SELECT version FROM product_component_version WHERE product LIKE 'Oracle%'"
+	}
+	if query != "" {
+		var versions []string
+		if err := db.Session(&gorm.Session{NewDB: true}).Raw(query).Scan(&versions).Error; err == nil && len(versions) > 0 {
+			caps.Version = versions[0]
+		}
+	}
+
+	switch caps.Dialect {
+	case "postgres":
+		caps.Returning, caps.Upsert, caps.RowLocking, caps.ILike = true, caps.atLeast(9, 5), true, true
+		caps.SkipLocked, caps.OffsetFetch = caps.atLeast(9, 5), true
+	case "mysql":
+		caps.Upsert, caps.RowLocking = true, true
+		if strings.Contains(strings.ToLower(caps.Version), "mariadb") {
+			caps.Returning, caps.SkipLocked = caps.atLeast(10, 5), caps.atLeast(10, 6)
+		} else {
+			caps.SkipLocked = caps.atLeast(8, 0, 1)
+		}
+	case "sqlite":
+		caps.Returning, caps.Upsert = caps.atLeast(3, 35), caps.atLeast(3, 24)
+	case "sqlserver":
+		caps.OffsetFetch = caps.atLeast(11)
+	case "oracle":
+		caps.RowLocking, caps.SkipLocked, caps.OffsetFetch = true, true, caps.atLeast(12)
+	}
+
+	if err == nil {
+		capabilities.Store(pool, caps)
+	}
+	return caps
+}
+
+// atLeast reports whether the server version is at least the given one,
+// which is assumed when the version is unknown.
+func (c Capabilities) atLeast(version ...int) bool {
+	if c.Version == "" {
+		return true
+	}
+	parts := strings.FieldsFunc(c.Version, func(r rune) bool { return r == '.' || r == '-' || r == ' ' })
+	for i, want := range version {
+		if i >= len(parts) {
+			return false
+		}
+		digits := parts[i]
+		if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			digits = digits[:end]
+		}
+		got, err := strconv.Atoi(digits)
+		if err != nil {
+			return true
+		}
+		if got != want {
+			return got > want
+		}
+	}
+	return true
+}
+
+// forUpdate locks the rows db reads until the transaction ends, where the
+// database locks rows; SQLite locks the whole database on write instead.
+// With skipLocked, rows locked by others are skipped where supported.
+func forUpdate(db *gorm.DB, skipLocked bool) *gorm.DB {
+	caps := DetectCapabilities(db)
+	if !caps.RowLocking {
+		return db
+	}
+	locking := clause.Locking{Strength: clause.LockingStrengthUpdate}
+	if skipLocked && caps.SkipLocked {
+		locking.Options = clause.LockingOptionsSkipLocked
+	}
+	return db.Clauses(locking)
+}
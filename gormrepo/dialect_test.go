@@ -0,0 +1,148 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		dialect, version string
+		want             Capabilities
+	}{
+		{"postgres", "16.2 (Debian 16.2-1.pgdg120+2)", Capabilities{Returning: true, Upsert: true, RowLocking: true, SkipLocked: true, ILike: true, OffsetFetch: true}},
+		{"postgres", "9.4.26", Capabilities{Returning: true, RowLocking: true, ILike: true, OffsetFetch: true}},
+		{"mysql", "8.0.36", Capabilities{Upsert: true, RowLocking: true, SkipLocked: true}},
+		{"mysql", "5.7.44-log", Capabilities{Upsert: true, RowLocking: true}},
+		{"mysql", "10.6.16-MariaDB", Capabilities{Returning: true, Upsert: true, RowLocking: true, SkipLocked: true}},
+		{"mysql", "10.4.32-MariaDB", Capabilities{Upsert: true, RowLocking: true}},
+		{"sqlite", "3.45.1", Capabilities{Returning: true, Upsert: true}},
+		{"sqlite", "3.31.1", Capabilities{Upsert: true}},
+		{"sqlite", "3.22.0", Capabilities{}},
+		// Current releases are assumed when the version can't be read
+		{"sqlite", "", Capabilities{Returning: true, Upsert: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.version, func(t *testing.T) {
+			db, _ := newTestDB(t, tt.dialect, tt.version)
+			tt.want.Dialect, tt.want.Version = tt.dialect, tt.version
+			if got := DetectCapabilities(db); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCapabilitiesReadsVersionOnce(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	DetectCapabilities(db)
+	DetectCapabilities(db.Session(&gorm.Session{}))
+	if versions := fake.ranLike("server_version"); len(versions) != 1 {
+		t.Errorf("read the version %d times", len(versions))
+	}
+}
+
+func TestWhereILikeFallback(t *testing.T) {
+	tests := []struct {
+		dialect, version, want string
+	}{
+		{"postgres", "16.2", `"name" ILIKE ?`},
+		{"mysql", "8.0.36", "LOWER(`name`) LIKE LOWER(?)"},
+		{"sqlite", "3.45.1", `LOWER("name") LIKE LOWER(?)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			db, fake := newTestDB(t, tt.dialect, tt.version)
+			if _, err := New[testItem](db).WhereILike("name", "%lamp%").Get(); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.ranLike(" FROM "); len(got) != 1 || !strings.Contains(got[0], tt.want) {
+				t.Errorf("ran %q, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForUpdateSkipLockedFallback(t *testing.T) {
+	tests := []struct {
+		dialect, version, want string
+	}{
+		{"postgres", "16.2", "FOR UPDATE SKIP LOCKED"},
+		{"postgres", "9.4.26", "FOR UPDATE"},
+		{"mysql", "8.0.36", "FOR UPDATE SKIP LOCKED"},
+		{"mysql", "5.7.44", "FOR UPDATE"},
+		{"sqlite", "3.45.1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.version, func(t *testing.T) {
+			db, fake := newTestDB(t, tt.dialect, tt.version)
+			var items []testItem
+			if err := forUpdate(db.Model(&testItem{}), true).Find(&items).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			got := fake.ranLike(" FROM ")
+			if len(got) != 1 {
+				t.Fatalf("ran %q", got)
+			}
+			locking := ""
+			if i := strings.Index(got[0], "FOR UPDATE"); i >= 0 {
+				locking = got[0][i:]
+			}
+			if locking != tt.want {
+				t.Errorf("ran %q, want locking %q", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestNextCounterReturningFallback(t *testing.T) {
+	tests := []struct {
+		dialect, version string
+		want             []string // Statements run after the version query
+	}{
+		{"postgres", "16.2", []string{
+			`INSERT INTO "counters" (name, value) VALUES (?, 1) ON CONFLICT (name) DO UPDATE SET value = "counters".value + 1 RETURNING value [invoices]`,
+		}},
+		{"sqlite", "3.45.1", []string{
+			`INSERT INTO "counters" (name, value) VALUES (?, 1) ON CONFLICT (name) DO UPDATE SET value = "counters".value + 1 RETURNING value [invoices]`,
+		}},
+		{"sqlite", "3.31.1", []string{
+			"BEGIN",
+			`UPDATE "counters" SET "value"=value + 1 WHERE name = ? [invoices]`,
+			`SELECT "value" FROM "counters" WHERE name = ? [invoices]`,
+			"COMMIT",
+		}},
+		{"mysql", "8.0.36", []string{
+			"BEGIN",
+			"INSERT INTO `counters` (name, value) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE value = LAST_INSERT_ID(value + 1) [invoices]",
+			"SELECT LAST_INSERT_ID()",
+			"COMMIT",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.version, func(t *testing.T) {
+			db, fake := newTestDB(t, tt.dialect, tt.version)
+			fake.queue([]string{"value"}, []driver.Value{int64(42)})
+
+			value, err := New[testItem](db).NextCounter("invoices")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != 42 {
+				t.Errorf("got %d, want 42", value)
+			}
+			got := fake.ran()[1:]
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("ran\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
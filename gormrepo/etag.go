@@ -0,0 +1,104 @@
+package gormrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spirandev/go-gormrepo/gormrepo/internal/pkhelper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ETag returns a strong HTTP entity tag hashing the persisted columns of
+// entity. Entities loaded from the same row version through repositories
+// configured alike get the same tag.
+func (r *GenericRepository[T]) ETag(entity *T) (string, error) {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return "", err
+	}
+	return entityTag(r.context(), s, reflect.ValueOf(entity).Elem())
+}
+
+// ConditionalUpdate saves entity only if the stored row still has the tag
+// ifMatch, as sent in an If-Match header, and fails with
+// ErrPreconditionFailed otherwise. "*" matches any stored row. The stored
+// row is tagged as loaded by the repository, so ifMatch should come from
+// ETag of an entity loaded with the same locale and column policy.
+func (e *Executor[T]) ConditionalUpdate(entity *T, ifMatch string) ExecutionResult[T] {
+	r := e.repo
+	if err := r.prepare(OpUpdate, entity); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+	_, pkValue, err := pkhelper.GetPrimaryKey(entity)
+	if err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	save := func(db *gorm.DB) error {
+		res := db.Save(entity)
+		rows = res.RowsAffected
+		return res.Error
+	}
+	if next, ok := r.entityState(entity); ok {
+		save = r.withTransitionCheck(entity, next, save)
+	}
+
+	err = r.write(OpUpdate, entity, func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			var stored T
+			err := r.redacted(forUpdate(tx.Session(&gorm.Session{NewDB: true}), false)).
+				Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: r.primaryKeyColumn()}, Value: pkValue}).
+				First(&stored).Error
+			if err != nil {
+				return err
+			}
+			if err := r.complete(&stored); err != nil {
+				return err
+			}
+			if ifMatch != "*" {
+				tag, err := entityTag(r.context(), s, reflect.ValueOf(&stored).Elem())
+				if err != nil {
+					return err
+				}
+				if strings.TrimPrefix(ifMatch, "W/") != tag {
+					return ErrPreconditionFailed
+				}
+			}
+			return save(tx)
+		})
+	})
+	return executionResult(entity, rows, err)
+}
+
+func entityTag(ctx context.Context, s *schema.Schema, row reflect.Value) (string, error) {
+	values := columnValues(ctx, s, row)
+	for column, value := range values {
+		value = columnValue(value)
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		values[column] = value
+	}
+	// encoding/json sorts map keys, keeping the hash stable
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
@@ -0,0 +1,214 @@
+package gormrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrEventDispatch = errors.New("event dispatch failed")
+
+// EventRecorder is implemented by aggregates that record domain events while
+// their state changes. Events are collected after a successful write.
+type EventRecorder interface {
+	RecordedEvents() []any
+	ClearEvents()
+}
+
+// NamedEvent lets an event choose the type name stored in the outbox.
+type NamedEvent interface {
+	EventName() string
+}
+
+type EventHandler func(ctx context.Context, event any) error
+
+type OutboxMessage struct {
+	ID          uint64 `gorm:"primaryKey;autoIncrement"`
+	EventType   string `gorm:"size:255;index"`
+	Payload     []byte
+	CreatedAt   time.Time
+	PublishedAt *time.Time `gorm:"index"`
+	Attempts    int
+	LastError   string
+	ParkedAt    *time.Time `gorm:"index"` // Set once publishing failed max attempts times
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+func (r *GenericRepository[T]) OnEvent(handlers ...EventHandler) *GenericRepository[T] {
+	r.eventHandlers = append(r.eventHandlers, handlers...)
+	return r
+}
+
+func (r *GenericRepository[T]) WithOutbox() *GenericRepository[T] {
+	r.useOutbox = true
+	return r
+}
+
+// write runs a persistence operation and takes care of what follows it: outbox
+// rows for recorded domain events are written in the same transaction, event
+// handlers and commit hooks run once the data is committed. Their errors are
+// returned as a committedError.
+func (r *GenericRepository[T]) write(kind OperationKind, target any, op func(db *gorm.DB) error) error {
+	recorders := eventRecorders(target)
+
+	var events []any
+	for _, recorder := range recorders {
+		events = append(events, recorder.RecordedEvents()...)
+	}
+
+	restore, err := r.externalizeBlobs(kind, target)
+	if err != nil {
+		return err
+	}
+
+	db := r.writable(kind, r.withOperationContext(r.writeDB(target), kind))
+	if kind == OpCreate && r.asyncInsert {
+		db = db.Clauses(asyncInsertValues{}).Session(&gorm.Session{})
+	}
+	op = r.withIdentityInsert(kind, target, op)
+	op = r.withQuota(kind, target, op)
+	op = r.withTranslations(kind, target, op)
+
+	work := &pendingWork[T]{events: events}
+	err = r.runOn(db, kind, target, work, func() error {
+		if len(events) == 0 || !r.useOutbox {
+			return op(db)
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := op(tx); err != nil {
+				return err
+			}
+			return writeOutbox(tx, events)
+		})
+	})
+	restore()
+	if err != nil {
+		return err
+	}
+	work.uncached = r.cacheKeys(target)
+	if memo := memoFromContext(r.context()); memo != nil {
+		memo.reset()
+	}
+
+	for _, recorder := range recorders {
+		recorder.ClearEvents()
+	}
+
+	work.changes = r.snapshot(kind, target)
+	if r.pending != nil {
+		r.pending.add(work)
+		return nil
+	}
+
+	if err := r.committed(work); err != nil {
+		return &committedError{err}
+	}
+	return nil
+}
+
+// writeWhere runs write for a write of the rows matching cond, which are
+// known but not given as entities. Middleware and op see the chain with cond
+// added.
+func (r *GenericRepository[T]) writeWhere(kind OperationKind, cond any, op func(db *gorm.DB) error) error {
+	scoped := r.clone(r.db.Session(&gorm.Session{}).Where(cond))
+	scoped.pending = r.pending
+	err := scoped.write(kind, nil, op)
+	if scoped.writeToken != "" {
+		r.writeToken = scoped.writeToken
+	}
+	return err
+}
+
+// committedError is returned by write when the data was committed but the
+// operation log, event handlers or commit hooks run afterwards failed.
+type committedError struct {
+	err error
+}
+
+func (e *committedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *committedError) Unwrap() error {
+	return e.err
+}
+
+func (r *GenericRepository[T]) dispatchEvents(events []any) error {
+	if len(r.eventHandlers) == 0 {
+		return nil
+	}
+
+	ctx := r.context()
+
+	var errs []error
+	for _, event := range events {
+		for _, handler := range r.eventHandlers {
+			if err := handler(ctx, event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrEventDispatch, errors.Join(errs...))
+	}
+	return nil
+}
+
+func writeOutbox(tx *gorm.DB, events []any) error {
+	messages := make([]OutboxMessage, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("error serializing event %T: %w", event, err)
+		}
+		messages = append(messages, OutboxMessage{EventType: eventName(event), Payload: payload})
+	}
+	return tx.Create(&messages).Error
+}
+
+func eventName(event any) string {
+	if named, ok := event.(NamedEvent); ok {
+		return named.EventName()
+	}
+
+	t := reflect.TypeOf(event)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func eventRecorders(target any) []EventRecorder {
+	if recorder, ok := target.(EventRecorder); ok {
+		return []EventRecorder{recorder}
+	}
+
+	val := reflect.ValueOf(target)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice {
+		return nil
+	}
+
+	var recorders []EventRecorder
+	for i := 0; i < val.Len(); i++ {
+		elem := val.Index(i)
+		if elem.Kind() != reflect.Ptr && elem.CanAddr() {
+			elem = elem.Addr()
+		}
+		if recorder, ok := elem.Interface().(EventRecorder); ok {
+			recorders = append(recorders, recorder)
+		}
+	}
+	return recorders
+}
@@ -0,0 +1,198 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spirandev/go-gormrepo/gormrepo/internal/pkhelper"
+	"gorm.io/gorm"
+)
+
+// ExecutionResult is the outcome of one write.
+type ExecutionResult[T any] struct {
+	Entity       *T
+	Entities     *[]T // Set by batch writes instead of Entity
+	RowsAffected int64
+	Err          error
+	// CommitErr holds the errors of the event handlers and commit hooks run
+	// once the write was committed; Err is nil then.
+	CommitErr error
+}
+
+// Executor runs writes that return their outcome as an ExecutionResult
+// rather than keeping it in the repository, so a repository can be reused
+// without one call seeing the result or error of another.
+type Executor[T any] struct {
+	repo *GenericRepository[T]
+}
+
+// Exec returns an executor for the repository's database and options.
+func (r *GenericRepository[T]) Exec() *Executor[T] {
+	return &Executor[T]{repo: r}
+}
+
+func (e *Executor[T]) Create(entity *T) ExecutionResult[T] {
+	r := e.repo
+	if err := r.prepare(OpCreate, entity); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	err := r.write(OpCreate, entity, func(db *gorm.DB) error {
+		res := db.Create(entity)
+		rows = res.RowsAffected
+		return res.Error
+	})
+	return executionResult(entity, rows, err)
+}
+
+func (e *Executor[T]) CreateBatch(entities *[]T) ExecutionResult[T] {
+	r := e.repo
+	if err := r.prepare(OpCreate, entities); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	err := r.write(OpCreate, entities, func(db *gorm.DB) error {
+		res := db.Create(entities)
+		rows = res.RowsAffected
+		return res.Error
+	})
+	return batchResult(entities, rows, err)
+}
+
+func (e *Executor[T]) Update(entity *T) ExecutionResult[T] {
+	r := e.repo
+	if err := r.prepare(OpUpdate, entity); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	save := func(db *gorm.DB) error {
+		db, err := r.loadedOnly(db)
+		if err != nil {
+			return err
+		}
+		res := db.Save(entity)
+		rows = res.RowsAffected
+		return res.Error
+	}
+	if next, ok := r.entityState(entity); ok {
+		save = r.withTransitionCheck(entity, next, save)
+	}
+	err := r.write(OpUpdate, entity, save)
+	return executionResult(entity, rows, err)
+}
+
+// UpdateWithPreload saves entity and returns it reloaded with the given
+// associations. When it can't be reloaded, the saved entity is returned.
+func (e *Executor[T]) UpdateWithPreload(entity *T, associations ...string) ExecutionResult[T] {
+	res := e.Update(entity)
+	if res.Err != nil {
+		return res
+	}
+
+	r := e.repo
+	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
+	if err != nil {
+		return res
+	}
+	var updatedEntity T
+	query := r.db
+	for _, association := range associations {
+		query = query.Preload(association)
+	}
+	if err := query.First(&updatedEntity, fmt.Sprintf("%s = ?", pkName), pkValue).Error; err != nil {
+		return res
+	}
+	r.afterLoad(&updatedEntity)
+	res.Entity = &updatedEntity
+	return res
+}
+
+func (e *Executor[T]) UpdateFields(entity *T, fields map[string]interface{}) ExecutionResult[T] {
+	r := e.repo
+	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
+	if err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+	if err := validateEnumFields(r.db, entity, fields); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	update := func(db *gorm.DB) error {
+		res := db.Model(entity).Where(fmt.Sprintf("%s = ?", pkName), pkValue).Updates(r.utcFields(fields))
+		rows = res.RowsAffected
+		return res.Error
+	}
+	if next, ok := r.fieldsState(fields); ok {
+		update = r.withTransitionCheck(entity, next, update)
+	}
+	err = r.write(OpUpdate, entity, update)
+	return executionResult(entity, rows, err)
+}
+
+func (e *Executor[T]) Delete(id int64) ExecutionResult[T] {
+	var rows int64
+	err := e.repo.write(OpDelete, nil, func(db *gorm.DB) error {
+		res := db.Delete(new(T), id)
+		rows = res.RowsAffected
+		return res.Error
+	})
+	if err == nil || errors.As(err, new(*committedError)) {
+		e.repo.onCommit(func() error {
+			e.repo.uncacheID(id)
+			return nil
+		})
+	}
+	return executionResult[T](nil, rows, err)
+}
+
+func (e *Executor[T]) DeleteEntity(entity *T) ExecutionResult[T] {
+	var rows int64
+	err := e.repo.write(OpDelete, entity, func(db *gorm.DB) error {
+		res := db.Delete(entity)
+		rows = res.RowsAffected
+		return res.Error
+	})
+	return executionResult(entity, rows, err)
+}
+
+func executionResult[T any](entity *T, rows int64, err error) ExecutionResult[T] {
+	var committed *committedError
+	if errors.As(err, &committed) {
+		return ExecutionResult[T]{Entity: entity, RowsAffected: rows, CommitErr: committed.err}
+	}
+	if err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+	return ExecutionResult[T]{Entity: entity, RowsAffected: rows}
+}
+
+func batchResult[T any](entities *[]T, rows int64, err error) ExecutionResult[T] {
+	res := executionResult[T](nil, rows, err)
+	if res.Err == nil {
+		res.Entities = entities
+	}
+	return res
+}
+
+// keep stores the outcome of a write in the repository, for the methods
+// predating Exec.
+func (r *GenericRepository[T]) keep(res ExecutionResult[T]) *GenericRepository[T] {
+	if res.Err != nil {
+		r.lastError = res.Err
+		return r
+	}
+	if res.CommitErr != nil {
+		r.lastError = res.CommitErr
+	}
+	if res.Entity != nil {
+		r.currentResult = res.Entity
+	}
+	if res.Entities != nil {
+		r.currentSlice = res.Entities
+	}
+	return r
+}
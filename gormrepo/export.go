@@ -0,0 +1,71 @@
+package gormrepo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SubjectExport is the data held about one subject, by table.
+type SubjectExport struct {
+	Subject    any                         `json:"subject"`
+	ExportedAt time.Time                   `json:"exported_at"`
+	Tables     map[string][]map[string]any `json:"tables"`
+}
+
+// ExportSubject returns, as JSON, the row of the entity with primary key
+// subjectKey and the rows reached through associations, e.g. "Orders" or
+// "Orders.Payments". Each association is loaded with one batched query.
+func (r *GenericRepository[T]) ExportSubject(subjectKey any, associations ...string) ([]byte, error) {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, gorm.ErrPrimaryKeyRequired
+	}
+
+	entity := new(T)
+	err = r.run(OpQuery, nil, func() error {
+		query := r.readDB(r.db).Where(map[string]any{s.PrioritizedPrimaryField.DBName: subjectKey})
+		for _, association := range associations {
+			query = query.Preload(association)
+		}
+		return query.Take(entity).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export := SubjectExport{
+		Subject:    subjectKey,
+		ExportedAt: time.Now().UTC(),
+		Tables:     map[string][]map[string]any{s.Table: {columnValues(r.context(), s, reflect.ValueOf(entity).Elem())}},
+	}
+	for _, association := range associations {
+		rows, relatedSchema, err := associatedRows(reflect.ValueOf(entity).Elem(), s, association)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			export.Tables[relatedSchema.Table] = append(export.Tables[relatedSchema.Table], columnValues(r.context(), relatedSchema, row))
+		}
+	}
+	return json.Marshal(export)
+}
+
+// columnValues returns the stored columns of row.
+func columnValues(ctx context.Context, s *schema.Schema, row reflect.Value) map[string]any {
+	values := make(map[string]any, len(s.DBNames))
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		values[field.DBName], _ = field.ValueOf(ctx, row)
+	}
+	return values
+}
@@ -0,0 +1,36 @@
+package gormrepo
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FirstByMax returns the entity with the largest value of field under the
+// chain conditions. Rows where it is NULL are skipped and ties go to the
+// lowest primary key.
+func (r *GenericRepository[T]) FirstByMax(field string) (*T, error) {
+	return r.firstBy(field, true)
+}
+
+// FirstByMin returns the entity with the smallest value of field, like FirstByMax.
+func (r *GenericRepository[T]) FirstByMin(field string) (*T, error) {
+	return r.firstBy(field, false)
+}
+
+func (r *GenericRepository[T]) firstBy(field string, desc bool) (*T, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	columns, err := r.columnsOf([]string{field})
+	if err != nil {
+		return nil, err
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: columns[0]}
+
+	// First orders by primary key after the extreme column, breaking ties.
+	// The query is built on a clone, leaving the chain of r as it was.
+	query := r.db.Session(&gorm.Session{}).
+		Where(clause.Neq{Column: column, Value: nil}).
+		Order(clause.OrderByColumn{Column: column, Desc: desc})
+	return r.clone(query).singleResult()
+}
@@ -0,0 +1,228 @@
+package gormrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// fakeDB is a database/sql connector recording the statements it runs and
+// answering queries with queued rows, so tests can check the SQL built for a
+// dialect without a server.
+type fakeDB struct {
+	mu         sync.Mutex
+	version    string // Answer to server version queries, which fail when empty
+	statements []string
+	rows       []*fakeRows
+	failOn     string // Statements containing it fail
+	unaffected string // Writes containing it affect no rows
+}
+
+// newTestDB opens a gorm database of the given dialect and server version on
+// a fakeDB.
+func newTestDB(t *testing.T, dialect, version string) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{version: version}
+	db, err := gorm.Open(fakeDialector{name: dialect, fake: fake}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+// queue adds the rows answering the next query that isn't a version query.
+func (f *fakeDB) queue(columns []string, values ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows = append(f.rows, &fakeRows{columns: columns, values: values})
+}
+
+// ran returns the statements run so far, with their arguments.
+func (f *fakeDB) ran() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.statements...)
+}
+
+// ranLike returns the statements run so far that contain s.
+func (f *fakeDB) ranLike(s string) []string {
+	var matched []string
+	for _, stmt := range f.ran() {
+		if strings.Contains(stmt, s) {
+			matched = append(matched, stmt)
+		}
+	}
+	return matched
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	stmt := query
+	if len(values) > 0 {
+		stmt += fmt.Sprint(" ", values)
+	}
+	f.statements = append(f.statements, stmt)
+	if f.failOn != "" && strings.Contains(query, f.failOn) {
+		return errors.New("fake failure")
+	}
+	return nil
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{f} }
+
+type fakeDriver struct{ fake *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d.fake}, nil }
+
+type fakeConn struct{ fake *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{c.fake}, c.fake.record("BEGIN", nil)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.fake.record(query, args); err != nil {
+		return nil, err
+	}
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	if c.fake.unaffected != "" && strings.Contains(query, c.fake.unaffected) {
+		return fakeResult{affected: 0}, nil
+	}
+	return fakeResult{affected: 1}, nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.fake.record(query, args); err != nil {
+		return nil, err
+	}
+	f := c.fake
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if strings.Contains(strings.ToLower(query), "version") {
+		if f.version == "" {
+			return nil, errors.New("unknown version")
+		}
+		return &fakeRows{columns: []string{"version"}, values: [][]driver.Value{{f.version}}}, nil
+	}
+	if len(f.rows) == 0 {
+		return &fakeRows{}, nil
+	}
+	rows := f.rows[0]
+	f.rows = f.rows[1:]
+	return rows, nil
+}
+
+type fakeTx struct{ fake *fakeDB }
+
+func (tx fakeTx) Commit() error   { return tx.fake.record("COMMIT", nil) }
+func (tx fakeTx) Rollback() error { return tx.fake.record("ROLLBACK", nil) }
+
+type fakeResult struct{ affected int64 }
+
+func (fakeResult) LastInsertId() (int64, error)   { return 0, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakeDialector stands in for the driver of a dialect: statements get its
+// name and quoting, and run on a fakeDB.
+type fakeDialector struct {
+	name string
+	fake *fakeDB
+}
+
+func (d fakeDialector) Name() string { return d.name }
+
+func (d fakeDialector) Initialize(db *gorm.DB) error {
+	config := &callbacks.Config{}
+	if d.name == "postgres" || d.name == "sqlite" {
+		// As their drivers do, so writes can return rows
+		config.CreateClauses = []string{"INSERT", "VALUES", "ON CONFLICT", "RETURNING"}
+		config.UpdateClauses = []string{"UPDATE", "SET", "FROM", "WHERE", "RETURNING"}
+		config.DeleteClauses = []string{"DELETE", "FROM", "WHERE", "RETURNING"}
+	}
+	callbacks.RegisterDefaultCallbacks(db, config)
+	db.ConnPool = sql.OpenDB(d.fake)
+	return nil
+}
+
+func (d fakeDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}}
+}
+
+func (d fakeDialector) DataTypeOf(*schema.Field) string { return "text" }
+
+func (d fakeDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (d fakeDialector) BindVarTo(w clause.Writer, _ *gorm.Statement, _ interface{}) {
+	w.WriteByte('?')
+}
+
+func (d fakeDialector) QuoteTo(w clause.Writer, s string) {
+	quote := byte('"')
+	if d.name == "mysql" {
+		quote = '`'
+	}
+	for i, part := range strings.Split(s, ".") {
+		if i > 0 {
+			w.WriteByte('.')
+		}
+		w.WriteByte(quote)
+		w.WriteString(part)
+		w.WriteByte(quote)
+	}
+}
+
+func (d fakeDialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
+
+func (d fakeDialector) SavePoint(tx *gorm.DB, name string) error {
+	return tx.Exec("SAVEPOINT " + name).Error
+}
+
+func (d fakeDialector) RollbackTo(tx *gorm.DB, name string) error {
+	return tx.Exec("ROLLBACK TO SAVEPOINT " + name).Error
+}
@@ -0,0 +1,193 @@
+package gormrepo
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SourceError is the failure of one database of a fan-out query.
+type SourceError struct {
+	Source int // Index of the database in the FanOut call
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("source %d: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+type FanOutResult[T any] struct {
+	Items  []T
+	Errors []*SourceError // Sources that failed; their rows are missing from Items
+}
+
+// FanOut runs the chain on each of dbs concurrently, for entities spread
+// over databases of the same dialect, and merges the rows in the chain's
+// ORDER BY and LIMIT. Failed sources are reported in the result; an error is
+// only returned when every source failed.
+func (r *GenericRepository[T]) FanOut(dbs ...*gorm.DB) (*FanOutResult[T], error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if len(dbs) == 0 {
+		return nil, fmt.Errorf("fan-out needs at least one database")
+	}
+	if currentOffset(r.db) > 0 {
+		return nil, fmt.Errorf("fan-out queries cannot use Offset")
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	less, err := orderComparator[T](r.db, s)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]T, len(dbs))
+	errs := make([]error, len(dbs))
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			query := r.db.Session(&gorm.Session{Context: r.context()})
+			query.Statement.ConnPool = db.Statement.ConnPool
+			errs[i] = r.run(OpQuery, nil, func() error {
+				return r.redacted(query).Find(&results[i]).Error
+			})
+		}()
+	}
+	wg.Wait()
+
+	result := &FanOutResult[T]{}
+	for i, err := range errs {
+		if err != nil {
+			result.Errors = append(result.Errors, &SourceError{Source: i, Err: err})
+			continue
+		}
+		result.Items = append(result.Items, results[i]...)
+	}
+	if len(result.Errors) == len(dbs) {
+		joined := make([]error, len(result.Errors))
+		for i, err := range result.Errors {
+			joined[i] = err
+		}
+		return nil, errors.Join(joined...)
+	}
+
+	if less != nil {
+		slices.SortStableFunc(result.Items, less)
+	}
+	if limit, ok := currentLimit(r.db); ok && len(result.Items) > limit {
+		result.Items = result.Items[:limit]
+	}
+	r.afterLoad(pointersTo(result.Items)...)
+	return result, nil
+}
+
+// orderComparator compares entities by the ORDER BY of db, or returns nil
+// when it has none.
+func orderComparator[T any](db *gorm.DB, s *schema.Schema) (func(a, b T) int, error) {
+	c, ok := db.Statement.Clauses["ORDER BY"]
+	if !ok {
+		return nil, nil
+	}
+	orderBy, ok := c.Expression.(clause.OrderBy)
+	if !ok || orderBy.Expression != nil {
+		return nil, fmt.Errorf("fan-out cannot merge rows ordered by an expression")
+	}
+
+	type sortKey struct {
+		field *schema.Field
+		desc  bool
+	}
+	var keys []sortKey
+	for _, column := range orderBy.Columns {
+		terms := []string{column.Column.Name}
+		if column.Column.Raw {
+			terms = strings.Split(column.Column.Name, ",")
+		}
+		for _, term := range terms {
+			parts := strings.Fields(term)
+			if len(parts) == 0 {
+				continue
+			}
+			name := parts[0]
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			field := s.LookUpField(strings.Trim(name, "\"`"))
+			if field == nil {
+				return nil, fmt.Errorf("fan-out cannot merge rows ordered by %s", term)
+			}
+			desc := column.Desc || (len(parts) > 1 && strings.EqualFold(parts[1], "desc"))
+			keys = append(keys, sortKey{field: field, desc: desc})
+		}
+	}
+
+	return func(a, b T) int {
+		va, vb := reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem()
+		for _, key := range keys {
+			x, _ := key.field.ValueOf(db.Statement.Context, va)
+			y, _ := key.field.ValueOf(db.Statement.Context, vb)
+			n := compareValues(reflect.ValueOf(x), reflect.ValueOf(y))
+			if key.desc {
+				n = -n
+			}
+			if n != 0 {
+				return n
+			}
+		}
+		return 0
+	}, nil
+}
+
+// compareValues orders two values of the same field, with nil first.
+func compareValues(x, y reflect.Value) int {
+	for x.IsValid() && x.Kind() == reflect.Ptr {
+		x = x.Elem()
+	}
+	for y.IsValid() && y.Kind() == reflect.Ptr {
+		y = y.Elem()
+	}
+	switch {
+	case !x.IsValid() || !y.IsValid():
+		return cmp.Compare(boolRank(x.IsValid()), boolRank(y.IsValid()))
+	case x.CanInt():
+		return cmp.Compare(x.Int(), y.Int())
+	case x.CanUint():
+		return cmp.Compare(x.Uint(), y.Uint())
+	case x.CanFloat():
+		return cmp.Compare(x.Float(), y.Float())
+	case x.Kind() == reflect.String:
+		return cmp.Compare(x.String(), y.String())
+	case x.Kind() == reflect.Bool:
+		return cmp.Compare(boolRank(x.Bool()), boolRank(y.Bool()))
+	}
+	if t, ok := x.Interface().(time.Time); ok {
+		return t.Compare(y.Interface().(time.Time))
+	}
+	return 0
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
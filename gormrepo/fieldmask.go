@@ -0,0 +1,96 @@
+package gormrepo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// fullMask is the field mask path selecting every field.
+const fullMask = "*"
+
+// ReadWithFieldMask loads the fields named by a google.protobuf.FieldMask
+// style mask, with nested paths such as "team.name" loading associations. An
+// empty mask or "*" loads whole entities.
+func (r *GenericRepository[T]) ReadWithFieldMask(mask []string) *GenericRepository[T] {
+	if len(mask) == 0 || slices.Contains(mask, fullMask) {
+		return r
+	}
+	return r.ProjectFromFieldSet(mask)
+}
+
+// UpdateWithFieldMask writes the fields of entity named by mask, following
+// google.protobuf.FieldMask semantics: masked fields are written even when
+// zero, an empty mask writes the fields that are set and "*" replaces all of
+// them. Paths name fields of T by column or Go field name.
+func (e *Executor[T]) UpdateWithFieldMask(entity *T, mask []string) ExecutionResult[T] {
+	r := e.repo
+	if err := r.prepare(OpUpdate, entity); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+	columns, err := maskColumns(s, mask)
+	if err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	update := func(db *gorm.DB) error {
+		db = db.Model(entity)
+		if columns != nil {
+			db = db.Select(columns)
+		}
+		res := db.Updates(entity)
+		rows = res.RowsAffected
+		return res.Error
+	}
+	if next, ok := r.entityState(entity); ok && r.masksState(s, columns, entity) {
+		update = r.withTransitionCheck(entity, next, update)
+	}
+	err = r.write(OpUpdate, entity, update)
+	return executionResult(entity, rows, err)
+}
+
+// maskColumns resolves a field mask to the columns it writes, or nil when
+// it writes the fields that are set.
+func maskColumns(s *schema.Schema, mask []string) ([]string, error) {
+	if len(mask) == 0 {
+		return nil, nil
+	}
+	if slices.Contains(mask, fullMask) {
+		return []string{fullMask}, nil
+	}
+
+	columns := make([]string, 0, len(mask))
+	for _, path := range mask {
+		field := lookUpFieldFold(s, path)
+		if strings.Contains(path, ".") || field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, path)
+		}
+		if !slices.Contains(columns, field.DBName) {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
+}
+
+// masksState reports whether an update of columns writes the state column.
+func (r *GenericRepository[T]) masksState(s *schema.Schema, columns []string, entity *T) bool {
+	field := s.LookUpField(r.stateMachine.Column)
+	if field == nil {
+		return false
+	}
+	if columns == nil {
+		_, isZero := field.ValueOf(r.context(), reflect.ValueOf(entity).Elem())
+		return !isZero
+	}
+	return slices.Contains(columns, fullMask) || slices.Contains(columns, field.DBName)
+}
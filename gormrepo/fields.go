@@ -0,0 +1,210 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var ErrUnknownField = errors.New("unknown field")
+
+// SelectFields limits the query to the given fields, named by column or Go
+// field name. Names come from callers such as report builders, so they are
+// checked against the schema of T and fail with ErrUnknownField.
+func (r *GenericRepository[T]) SelectFields(fields ...string) *GenericRepository[T] {
+	columns, err := r.columnsOf(fields)
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	r.db = r.db.Select(columns)
+	return r
+}
+
+// GetMaps executes the query and returns each row as a column/value map, for
+// result shapes only known at runtime.
+func (r *GenericRepository[T]) GetMaps() ([]map[string]interface{}, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if err := r.checkOffset(currentOffset(r.db)); err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	err := r.run(OpQuery, nil, func() error {
+		return r.redacted(r.readDB(r.limitedQuery())).Model(new(T)).Find(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkMaxRows(len(rows)); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// columnsOf resolves field or column names of T to columns.
+func (r *GenericRepository[T]) columnsOf(fields []string) ([]string, error) {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, name := range fields {
+		field := s.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, name)
+		}
+		columns = append(columns, field.DBName)
+	}
+	return columns, nil
+}
+
+// preloader preloads an association, selecting only columns when given.
+type preloader interface {
+	preloadColumns(association string, columns []string)
+}
+
+// fieldPlan is the columns and preloads needed to load a field set.
+type fieldPlan struct {
+	schema    *schema.Schema
+	all       bool
+	columns   []string
+	relations []string
+	children  map[string]*fieldPlan
+}
+
+// ProjectFromFieldSet loads only the fields a client asked for, such as a
+// GraphQL selection set or a REST ?fields= list. Nested fields use dotted
+// paths ("team.name"); a path ending at an association loads it whole.
+// Names may be Go field names in any case or column names. Keys needed to
+// stitch associations together are always loaded.
+func (r *GenericRepository[T]) ProjectFromFieldSet(fields []string) *GenericRepository[T] {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	plan := newFieldPlan(s)
+	for _, path := range fields {
+		if err := plan.add(strings.Split(path, ".")); err != nil {
+			r.lastError = err
+			return r
+		}
+	}
+
+	if !plan.all {
+		r.db = r.db.Select(plan.columns)
+	}
+	plan.preload(r, "")
+	r.spec.Fields = fields
+	return r
+}
+
+func newFieldPlan(s *schema.Schema) *fieldPlan {
+	p := &fieldPlan{schema: s, children: map[string]*fieldPlan{}}
+	for _, pk := range s.PrimaryFields {
+		p.addColumn(pk.DBName)
+	}
+	return p
+}
+
+func (p *fieldPlan) add(path []string) error {
+	name := path[0]
+	if field := lookUpFieldFold(p.schema, name); field != nil && field.DBName != "" && len(path) == 1 {
+		p.addColumn(field.DBName)
+		return nil
+	}
+
+	rel := lookUpRelationFold(p.schema, name)
+	if rel == nil {
+		return fmt.Errorf("%w: %s has no field %s", ErrUnknownField, p.schema.Name, name)
+	}
+
+	child, ok := p.children[rel.Name]
+	if !ok {
+		child = newFieldPlan(rel.FieldSchema)
+		p.children[rel.Name] = child
+		p.relations = append(p.relations, rel.Name)
+
+		// Keys joining the two sides must be loaded on each of them
+		for _, ref := range rel.References {
+			for _, key := range []*schema.Field{ref.PrimaryKey, ref.ForeignKey} {
+				switch {
+				case key == nil:
+				case key.Schema == p.schema:
+					p.addColumn(key.DBName)
+				case key.Schema == rel.FieldSchema:
+					child.addColumn(key.DBName)
+				}
+			}
+		}
+	}
+
+	if len(path) == 1 {
+		child.all = true
+		return nil
+	}
+	return child.add(path[1:])
+}
+
+func (p *fieldPlan) addColumn(column string) {
+	if !slices.Contains(p.columns, column) {
+		p.columns = append(p.columns, column)
+	}
+}
+
+func (p *fieldPlan) preload(r preloader, prefix string) {
+	for _, name := range p.relations {
+		child := p.children[name]
+		path := prefix + name
+
+		var columns []string
+		if !child.all {
+			columns = child.columns
+		}
+		r.preloadColumns(path, columns)
+		child.preload(r, path+".")
+	}
+}
+
+func (r *GenericRepository[T]) preloadColumns(association string, columns []string) {
+	if len(columns) == 0 {
+		r.db = r.db.Preload(association)
+		return
+	}
+	r.db = r.db.Preload(association, func(db *gorm.DB) *gorm.DB {
+		return db.Select(columns)
+	})
+}
+
+func lookUpFieldFold(s *schema.Schema, name string) *schema.Field {
+	if field := s.LookUpField(name); field != nil {
+		return field
+	}
+	for _, field := range s.Fields {
+		if strings.EqualFold(field.Name, name) || strings.EqualFold(field.DBName, name) {
+			return field
+		}
+	}
+	return nil
+}
+
+func lookUpRelationFold(s *schema.Schema, name string) *schema.Relationship {
+	if rel, ok := s.Relationships.Relations[name]; ok {
+		return rel
+	}
+	for relName, rel := range s.Relationships.Relations {
+		if strings.EqualFold(relName, name) || strings.EqualFold(toSnakeCase(relName), name) {
+			return rel
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package gormrepo
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var ErrFileNotUploaded = errors.New("file not uploaded")
+
+// FileField is a file column: the metadata of a file kept in a FileStorage.
+// Assign NewFileField to upload a file when the entity is written; URL is
+// resolved when the entity is loaded and is not stored. Projections to a
+// string field get the URL.
+type FileField struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url,omitempty"`
+
+	content io.Reader
+}
+
+// NewFileField returns a file uploaded from content by the next write of
+// the entity holding it.
+func NewFileField(name, contentType string, content io.Reader) FileField {
+	return FileField{Name: name, ContentType: contentType, content: content}
+}
+
+func (f FileField) Value() (driver.Value, error) {
+	if f.content != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotUploaded, f.Name)
+	}
+	if f.Key == "" {
+		return nil, nil
+	}
+	f.URL = ""
+	b, err := json.Marshal(f)
+	return string(b), err
+}
+
+func (f *FileField) Scan(value any) error {
+	*f = FileField{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return fmt.Errorf("cannot scan %T into FileField", value)
+}
+
+func (f FileField) MarshalText() ([]byte, error) {
+	return []byte(f.URL), nil
+}
+
+// MarshalJSON keeps the metadata in JSON, which would otherwise use
+// MarshalText.
+func (f FileField) MarshalJSON() ([]byte, error) {
+	type fileField FileField
+	return json.Marshal(fileField(f))
+}
+
+func (FileField) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "JSONB"
+	case "mysql":
+		return "JSON"
+	}
+	return "TEXT"
+}
+
+// FileStorage stores the content of file columns.
+type FileStorage interface {
+	Upload(ctx context.Context, key string, content io.Reader, contentType string) error
+	URL(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type localFileStorage struct {
+	dir, baseURL string
+}
+
+// NewLocalFileStorage stores files under dir, served at baseURL.
+func NewLocalFileStorage(dir, baseURL string) FileStorage {
+	return &localFileStorage{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *localFileStorage) Upload(ctx context.Context, key string, content io.Reader, contentType string) error {
+	name := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (s *localFileStorage) URL(ctx context.Context, key string) (string, error) {
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *localFileStorage) Delete(ctx context.Context, key string) error {
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// S3Client is the subset of an S3 client used by NewS3FileStorage.
+type S3Client interface {
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string) error
+	DeleteObject(ctx context.Context, bucket, key string) error
+	PresignGetObject(ctx context.Context, bucket, key string) (string, error)
+}
+
+type s3FileStorage struct {
+	client S3Client
+	bucket string
+}
+
+// NewS3FileStorage stores files in bucket, resolving presigned URLs.
+func NewS3FileStorage(client S3Client, bucket string) FileStorage {
+	return &s3FileStorage{client: client, bucket: bucket}
+}
+
+func (s *s3FileStorage) Upload(ctx context.Context, key string, content io.Reader, contentType string) error {
+	return s.client.PutObject(ctx, s.bucket, key, content, contentType)
+}
+
+func (s *s3FileStorage) URL(ctx context.Context, key string) (string, error) {
+	return s.client.PresignGetObject(ctx, s.bucket, key)
+}
+
+func (s *s3FileStorage) Delete(ctx context.Context, key string) error {
+	return s.client.DeleteObject(ctx, s.bucket, key)
+}
+
+// WithFileStorage keeps the FileField columns of T in storage: new files
+// are uploaded when their entity is written, URLs are resolved on load and
+// the files of entities deleted by value are removed once the deletion is
+// committed.
+func (r *GenericRepository[T]) WithFileStorage(storage FileStorage) *GenericRepository[T] {
+	r.fileStorage = storage
+	return r.AfterCommit(func(ctx context.Context, changes []Change[T]) error {
+		var errs []error
+		for _, change := range changes {
+			if change.Kind != OpDelete {
+				continue
+			}
+			for _, file := range entityFiles(reflect.ValueOf(&change.Entity).Elem()) {
+				if file.Key != "" {
+					errs = append(errs, storage.Delete(ctx, file.Key))
+				}
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// entityFiles returns the file fields of entity.
+func entityFiles(entity reflect.Value) []*FileField {
+	var files []*FileField
+	for i := 0; i < entity.NumField(); i++ {
+		switch field := entity.Field(i); field.Type() {
+		case reflect.TypeOf(FileField{}):
+			if field.CanAddr() && field.CanInterface() {
+				files = append(files, field.Addr().Interface().(*FileField))
+			}
+		case reflect.TypeOf(&FileField{}):
+			if !field.IsNil() && field.CanInterface() {
+				files = append(files, field.Interface().(*FileField))
+			}
+		}
+	}
+	return files
+}
+
+// uploadFiles uploads the new files of target.
+func (r *GenericRepository[T]) uploadFiles(target any) error {
+	if r.fileStorage == nil {
+		return nil
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+
+	ctx := r.context()
+	return forEachEntity(target, func(entity reflect.Value) error {
+		for _, file := range entityFiles(entity) {
+			if file.content == nil {
+				continue
+			}
+			id := make([]byte, 16)
+			if _, err := rand.Read(id); err != nil {
+				return err
+			}
+			key := path.Join(s.Table, hex.EncodeToString(id), path.Base(file.Name))
+
+			content := &countingReader{r: file.content}
+			if err := r.fileStorage.Upload(ctx, key, content, file.ContentType); err != nil {
+				return fmt.Errorf("uploading %s: %w", file.Name, err)
+			}
+			file.Key, file.Size, file.content = key, content.n, nil
+		}
+		return nil
+	})
+}
+
+// resolveFileURLs sets the URL of the files of loaded entities.
+func (r *GenericRepository[T]) resolveFileURLs(entities ...*T) error {
+	if r.fileStorage == nil {
+		return nil
+	}
+	ctx := r.context()
+	for _, entity := range entities {
+		if entity == nil {
+			continue
+		}
+		for _, file := range entityFiles(reflect.ValueOf(entity).Elem()) {
+			if file.Key == "" {
+				continue
+			}
+			url, err := r.fileStorage.URL(ctx, file.Key)
+			if err != nil {
+				return err
+			}
+			file.URL = url
+		}
+	}
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
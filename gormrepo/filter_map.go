@@ -0,0 +1,113 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// whereFilters ANDs one equality condition per entry of filters to db, in
+// key order.
+func (r *GenericRepository[T]) whereFilters(db *gorm.DB, filters map[string]any) *gorm.DB {
+	for _, k := range filterKeys(filters) {
+		query, args := r.filterCondition(db, k, filters[k])
+		db = db.Where(query, args...)
+	}
+	return db
+}
+
+func filterKeys(filters map[string]any) []string {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// filterCondition builds the condition matching key to value, with the
+// literal written the way the dialect stores it: nil is IS NULL, bools are
+// 1 and 0 where they're stored as integers, times are rounded to the
+// column's precision, maps or structs are compared as JSON and slices
+// other than bytes match any of their values.
+func (r *GenericRepository[T]) filterCondition(db *gorm.DB, key string, value any) (string, []any) {
+	if value == nil {
+		return key + " IS NULL", nil
+	}
+	if _, ok := value.(driver.Valuer); ok {
+		return key + " = ?", []any{value}
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return key + " IS NULL", nil
+		}
+		return r.filterCondition(db, key, rv.Elem().Interface())
+	}
+	dialect := db.Dialector.Name()
+
+	switch v := value.(type) {
+	case bool:
+		if dialect == "mysql" || dialect == "sqlite" {
+			if v {
+				return key + " = ?", []any{1}
+			}
+			return key + " = ?", []any{0}
+		}
+	case time.Time:
+		switch dialect {
+		case "postgres":
+			return key + " = ?", []any{v.Round(r.timePrecision(db, key, 6))}
+		case "mysql":
+			return key + " = ?", []any{v.Round(r.timePrecision(db, key, 3))}
+		case "sqlite":
+			// Stored as text in the writer's time zone
+			return "strftime('%Y-%m-%d %H:%M:%f', " + key + ") = strftime('%Y-%m-%d %H:%M:%f', ?)", []any{v}
+		}
+	case []byte:
+	default:
+		switch rv := reflect.ValueOf(value); rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			// Bytes, such as binary UUIDs, are a single value
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				b := make([]byte, rv.Len())
+				reflect.Copy(reflect.ValueOf(b), rv)
+				return key + " = ?", []any{b}
+			}
+			return key + " IN ?", []any{value}
+		case reflect.Map, reflect.Struct:
+			b, err := json.Marshal(value)
+			if err != nil {
+				break
+			}
+			switch dialect {
+			case "postgres":
+				return key + "::jsonb = ?::jsonb", []any{string(b)}
+			case "mysql":
+				return key + " = CAST(? AS JSON)", []any{string(b)}
+			case "sqlite":
+				return "json(" + key + ") = json(?)", []any{string(b)}
+			}
+			return key + " = ?", []any{string(b)}
+		}
+	}
+	return key + " = ?", []any{value}
+}
+
+// timePrecision returns the fractional second precision of the time
+// column key of T, or the dialect's default digits.
+func (r *GenericRepository[T]) timePrecision(db *gorm.DB, key string, digits int) time.Duration {
+	if s, err := parseSchema(db, new(T)); err == nil {
+		if field := lookUpFieldFold(s, key); field != nil && field.Precision > 0 && field.Precision < 9 {
+			digits = field.Precision
+		}
+	}
+	precision := time.Second
+	for i := 0; i < digits; i++ {
+		precision /= 10
+	}
+	return precision
+}
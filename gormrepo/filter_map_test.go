@@ -0,0 +1,74 @@
+package gormrepo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type filterItem struct {
+	ID       int64
+	Active   bool
+	SeenAt   time.Time
+	SyncedAt time.Time      `gorm:"precision:3"`
+	Meta     map[string]any `gorm:"serializer:json"`
+}
+
+func TestFilterCondition(t *testing.T) {
+	seen := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.UTC)
+	id := [4]byte{1, 2, 3, 4}
+	var missing *int64
+	count := int64(3)
+
+	tests := []struct {
+		name, dialect, key string
+		value              any
+		want               string
+		args               []any
+	}{
+		{"bool postgres", "postgres", "active", true, "active = ?", []any{true}},
+		{"bool mysql true", "mysql", "active", true, "active = ?", []any{1}},
+		{"bool mysql false", "mysql", "active", false, "active = ?", []any{0}},
+		{"bool sqlite", "sqlite", "active", true, "active = ?", []any{1}},
+
+		{"time postgres", "postgres", "seen_at", seen, "seen_at = ?", []any{seen.Round(time.Microsecond)}},
+		{"time mysql", "mysql", "seen_at", seen, "seen_at = ?", []any{seen.Round(time.Millisecond)}},
+		{"time column precision", "postgres", "synced_at", seen, "synced_at = ?", []any{seen.Round(time.Millisecond)}},
+		{"time sqlite", "sqlite", "seen_at", seen,
+			"strftime('%Y-%m-%d %H:%M:%f', seen_at) = strftime('%Y-%m-%d %H:%M:%f', ?)", []any{seen}},
+
+		{"json postgres", "postgres", "meta", map[string]any{"a": 1}, "meta::jsonb = ?::jsonb", []any{`{"a":1}`}},
+		{"json mysql", "mysql", "meta", map[string]any{"a": 1}, "meta = CAST(? AS JSON)", []any{`{"a":1}`}},
+		{"json sqlite", "sqlite", "meta", map[string]any{"a": 1}, "json(meta) = json(?)", []any{`{"a":1}`}},
+
+		{"nil", "postgres", "meta", nil, "meta IS NULL", nil},
+		{"nil pointer", "mysql", "count", missing, "count IS NULL", nil},
+		{"pointer", "mysql", "count", &count, "count = ?", []any{int64(3)}},
+		{"slice", "postgres", "id", []int64{1, 2}, "id IN ?", []any{[]int64{1, 2}}},
+		{"bytes", "postgres", "id", []byte{1, 2}, "id = ?", []any{[]byte{1, 2}}},
+		{"byte array", "mysql", "id", id, "id = ?", []any{[]byte{1, 2, 3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newTestDB(t, tt.dialect, "")
+			query, args := New[filterItem](db).filterCondition(db, tt.key, tt.value)
+			if query != tt.want || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("got %s %v, want %s %v", query, args, tt.want, tt.args)
+			}
+		})
+	}
+}
+
+func TestCountAppliesFiltersInKeyOrder(t *testing.T) {
+	db, fake := newTestDB(t, "mysql", "8.0.36")
+	_, err := New[filterItem](db).Count(map[string]interface{}{"synced_at": nil, "active": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT count(*) FROM `filter_items` WHERE active = ? AND synced_at IS NULL [1]"
+	if got := fake.ranLike(" FROM "); len(got) != 1 || !strings.HasPrefix(got[0], want) {
+		t.Errorf("ran %q, want %s", got, want)
+	}
+}
@@ -1,495 +1,769 @@
-package gormrepo
-
-import (
-	"context"
-	"fmt"
-	"reflect"
-	"strings"
-
-	"github.com/spirandev/go-gormrepo/gormrepo/internal/pkhelper"
-	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
-)
-
-func (r *GenericRepository[T]) Begin() (*gorm.DB, error) {
-	return r.db.Begin(), nil
-}
-
-func (r *GenericRepository[T]) Commit(tx *gorm.DB) error {
-	return tx.Commit().Error
-}
-
-func (r *GenericRepository[T]) Rollback(tx *gorm.DB) error {
-	return tx.Rollback().Error
-}
-
-func (r *GenericRepository[T]) singleResult() (*T, error) {
-	var entity T
-	err := r.db.First(&entity).Error
-	return &entity, err
-}
-
-func (r *GenericRepository[T]) listResult() (*[]T, error) {
-	var entities []T
-	err := r.db.Find(&entities).Error
-	return &entities, err
-}
-func (r *GenericRepository[T]) Create(entity *T) *GenericRepository[T] {
-	err := r.db.Create(entity).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-
-	r.currentResult = entity
-	return r
-}
-
-func (r *GenericRepository[T]) CreateWithPreload(entity *T, associations ...string) *GenericRepository[T] {
-	err := r.db.Create(entity).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-
-	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
-	if err != nil {
-		r.currentResult = entity
-		return r
-	}
-
-	var createdEntity T
-	query := r.db
-
-	for _, association := range associations {
-		query = query.Preload(association)
-	}
-
-	if err := query.First(&createdEntity, fmt.Sprintf("%s = ?", pkName), pkValue).Error; err != nil {
-		r.currentResult = entity
-		return r
-	}
-
-	r.currentResult = &createdEntity
-	return r
-}
-
-func (r *GenericRepository[T]) CreateWithAllAssociations(entity *T) *GenericRepository[T] {
-	err := r.db.Create(entity).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-
-	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
-	if err != nil {
-		r.currentResult = entity
-		return r
-	}
-
-	var createdEntity T
-	if err := r.db.Preload(clause.Associations).First(&createdEntity, fmt.Sprintf("%s = ?", pkName), pkValue).Error; err != nil {
-		r.currentResult = entity
-		return r
-	}
-
-	r.currentResult = &createdEntity
-	return r
-}
-
-func (r *GenericRepository[T]) CreateBatch(entities *[]T) *GenericRepository[T] {
-	err := r.db.Create(entities).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-
-	r.currentSlice = entities
-	return r
-}
-
-func (r *GenericRepository[T]) Update(entity *T) *GenericRepository[T] {
-	err := r.db.Save(entity).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-
-	r.currentResult = entity
-	return r
-}
-
-func (r *GenericRepository[T]) UpdateWithPreload(entity *T, associations ...string) *GenericRepository[T] {
-	err := r.db.Save(entity).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
-	if err != nil {
-		r.currentResult = entity
-		return r
-	}
-	var updatedEntity T
-	query := r.db
-	for _, association := range associations {
-		query = query.Preload(association)
-	}
-	if err := query.First(&updatedEntity, fmt.Sprintf("%s = ?", pkName), pkValue).Error; err != nil {
-		r.currentResult = entity
-		return r
-	}
-	r.currentResult = &updatedEntity
-	return r
-}
-
-func (r *GenericRepository[T]) UpdateFields(entity *T, fields map[string]interface{}) *GenericRepository[T] {
-	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-	err = r.db.Model(entity).Where(fmt.Sprintf("%s = ?", pkName), pkValue).Updates(fields).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-	r.currentResult = entity
-	return r
-}
-
-func getID(entity interface{}) interface{} {
-	val := reflect.ValueOf(entity).Elem()
-	return val.FieldByName("ID").Interface()
-}
-
-func (r *GenericRepository[T]) Delete(id int64) *GenericRepository[T] {
-	err := r.db.Delete(new(T), id).Error
-	if err != nil {
-		r.lastError = err
-	}
-	return r
-}
-
-func (r *GenericRepository[T]) DeleteEntity(entity *T) *GenericRepository[T] {
-	err := r.db.Delete(entity).Error
-	if err != nil {
-		r.lastError = err
-	}
-	return r
-}
-
-func (r *GenericRepository[T]) DeleteBatch(entities *[]T) *GenericRepository[T] {
-	err := r.db.Delete(entities).Error
-	if err != nil {
-		r.lastError = err
-	}
-	return r
-}
-
-func (r *GenericRepository[T]) FindByID(id int64) *GenericRepository[T] {
-	return r.Where("id = ?", id)
-}
-
-func (r *GenericRepository[T]) FindFirst() *GenericRepository[T] {
-	return r.Limit(1)
-}
-
-func (r *GenericRepository[T]) FindAll() *GenericRepository[T] {
-	// No need to do anything here, just return the repository
-	// The query will be executed when First(), Get() or One() are called
-	return r
-}
-
-func (r *GenericRepository[T]) Preload(associations ...string) *GenericRepository[T] {
-	for _, association := range associations {
-		r.db = r.db.Preload(association)
-	}
-	return r
-}
-
-func (r *GenericRepository[T]) WithJoins(joins ...string) *GenericRepository[T] {
-	for _, join := range joins {
-		r.db = r.db.Joins(join)
-	}
-	return r
-}
-
-func (r *GenericRepository[T]) Where(query interface{}, args ...interface{}) *GenericRepository[T] {
-	r.db = r.db.Where(query, args...)
-	return r
-}
-
-func (r *GenericRepository[T]) Order(value interface{}) *GenericRepository[T] {
-	r.db = r.db.Order(value)
-	return r
-}
-
-func (r *GenericRepository[T]) Count(filters map[string]interface{}) (int64, error) {
-	filterRepo := &GenericRepository[T]{db: r.db.Model(new(T))}
-	for k, v := range filters {
-		filterRepo = filterRepo.Where(k+" = ?", v)
-	}
-	var count int64
-	err := filterRepo.db.Count(&count).Error
-	return count, err
-}
-
-func (r *GenericRepository[T]) Exists(filters map[string]interface{}) (bool, error) {
-	count, err := r.Count(filters)
-	return count > 0, err
-}
-
-func (r *GenericRepository[T]) CreateWithContext(ctx context.Context, entity *T) *GenericRepository[T] {
-	contextRepo := &GenericRepository[T]{
-		db:             r.db.WithContext(ctx),
-		projection:     r.projection,
-		projectionMode: r.projectionMode,
-	}
-	return contextRepo.Create(entity)
-}
-
-func (r *GenericRepository[T]) FindByIDWithContext(ctx context.Context, id int64) *GenericRepository[T] {
-	contextRepo := &GenericRepository[T]{
-		db:             r.db.WithContext(ctx),
-		projection:     r.projection,
-		projectionMode: r.projectionMode,
-	}
-	return contextRepo.Where("id = ?", id)
-}
-
-func (r *GenericRepository[T]) FindOne(filters map[string]interface{}) *GenericRepository[T] {
-	// Apply filters to the existing db (which may already have preloads/joins configured)
-	query := r.db
-	for k, v := range filters {
-		query = query.Where(k+" = ?", v)
-	}
-
-	// Update the db to preserve the configuration for the next operations
-	r.db = query
-
-	// Execute query and store result for chaining
-	var entity T
-	err := r.db.First(&entity).Error
-	if err != nil {
-		r.lastError = err
-		return r
-	}
-
-	r.currentResult = &entity
-	return r
-}
-
-func (r *GenericRepository[T]) Limit(limit int) *GenericRepository[T] {
-	r.db = r.db.Limit(limit)
-	return r
-}
-
-func (r *GenericRepository[T]) Offset(offset int) *GenericRepository[T] {
-	r.db = r.db.Offset(offset)
-	return r
-}
-
-func (r *GenericRepository[T]) Paginate(page, pageSize int) *GenericRepository[T] {
-	offset := (page - 1) * pageSize
-	return r.Offset(offset).Limit(pageSize)
-}
-
-func (r *GenericRepository[T]) Transaction(fn func(tx *GenericRepository[T]) error) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		txRepo := &GenericRepository[T]{db: tx}
-		return fn(txRepo)
-	})
-}
-
-func (r *GenericRepository[T]) WithDB(db *gorm.DB) *GenericRepository[T] {
-	return &GenericRepository[T]{db: db}
-}
-
-func (r *GenericRepository[T]) Select(query interface{}, args ...interface{}) *GenericRepository[T] {
-	r.db = r.db.Select(query, args...)
-	return r
-}
-
-func (r *GenericRepository[T]) Group(name string) *GenericRepository[T] {
-	r.db = r.db.Group(name)
-	return r
-}
-
-func (r *GenericRepository[T]) Having(query interface{}, args ...interface{}) *GenericRepository[T] {
-	r.db = r.db.Having(query, args...)
-	return r
-}
-
-func (r *GenericRepository[T]) Or(query interface{}, args ...interface{}) *GenericRepository[T] {
-	r.db = r.db.Or(query, args...)
-	return r
-}
-
-func (r *GenericRepository[T]) Not(query interface{}, args ...interface{}) *GenericRepository[T] {
-	r.db = r.db.Not(query, args...)
-	return r
-}
-
-func (r *GenericRepository[T]) First() (*T, error) {
-	return r.singleResult()
-}
-
-func (r *GenericRepository[T]) Get() (*[]T, error) {
-	return r.listResult()
-}
-
-func (r *GenericRepository[T]) One() (*T, error) {
-	r.db = r.db.Limit(1)
-	return r.singleResult()
-}
-
-func (r *GenericRepository[T]) ProjectToDTO(dtoInterface interface{}) *GenericRepository[T] {
-	newRepo := &GenericRepository[T]{
-		db:             r.db,
-		projection:     dtoInterface,
-		projectionMode: "dto",
-		currentResult:  r.currentResult,
-		currentSlice:   r.currentSlice,
-		lastError:      r.lastError,
-	}
-	if hasStructFields(dtoInterface) {
-		preloads := extractPreloadsFromDTO(dtoInterface)
-
-		for _, preload := range preloads {
-			newRepo.db = newRepo.db.Preload(preload)
-		}
-	} else {
-		fields := createProjectionFromDTO(dtoInterface)
-		if len(fields) > 0 {
-			selectFields := strings.Join(fields, ", ")
-			newRepo.db = newRepo.db.Select(selectFields)
-		}
-	}
-
-	return newRepo
-}
-
-func (r *GenericRepository[T]) Project() (interface{}, error) {
-	if r.projection == nil {
-		return nil, fmt.Errorf("no projection configured - use ProjectToDTO() first")
-	}
-
-	if r.currentResult == nil {
-		return nil, fmt.Errorf("no current result available - execute a query first (FindOne, FindByID, etc.)")
-	}
-
-	return mapEntityToDTO(r.currentResult, r.projection)
-}
-
-func (r *GenericRepository[T]) HasError() bool {
-	return r.lastError != nil
-}
-
-func (r *GenericRepository[T]) Error() error {
-	return r.lastError
-}
-
-func (r *GenericRepository[T]) Result() (*T, error) {
-	return r.currentResult, r.lastError
-}
-
-func (r *GenericRepository[T]) Results() (*[]T, error) {
-	return r.currentSlice, r.lastError
-}
-
-func (r *GenericRepository[T]) Execute() error {
-	return r.lastError
-}
-
-func (r *GenericRepository[T]) ProjectEntity(entity *T, dtoInterface interface{}) (interface{}, error) {
-	if entity == nil {
-		return nil, fmt.Errorf("entity cannot be nil")
-	}
-
-	return mapEntityToDTO(entity, dtoInterface)
-}
-
-func (r *GenericRepository[T]) ProjectEntitySlice(entities *[]T, dtoInterface interface{}) (interface{}, error) {
-	if entities == nil {
-		return nil, fmt.Errorf("entity slice cannot be nil")
-	}
-
-	if len(*entities) == 0 {
-		dtoType := reflect.TypeOf(dtoInterface)
-		if dtoType.Kind() == reflect.Ptr {
-			dtoType = dtoType.Elem()
-		}
-		sliceType := reflect.SliceOf(dtoType)
-		return reflect.MakeSlice(sliceType, 0, 0).Interface(), nil
-	}
-
-	dtoType := reflect.TypeOf(dtoInterface)
-	if dtoType.Kind() == reflect.Ptr {
-		dtoType = dtoType.Elem()
-	}
-
-	sliceType := reflect.SliceOf(dtoType)
-	resultSlice := reflect.MakeSlice(sliceType, 0, len(*entities))
-
-	for _, entity := range *entities {
-		dto, err := mapEntityToDTO(&entity, dtoInterface)
-		if err != nil {
-			return nil, fmt.Errorf("error converting entity: %w", err)
-		}
-
-		dtoValue := reflect.ValueOf(dto)
-		if dtoValue.Kind() == reflect.Ptr {
-			dtoValue = dtoValue.Elem()
-		}
-		resultSlice = reflect.Append(resultSlice, dtoValue)
-	}
-
-	return resultSlice.Interface(), nil
-}
-
-func (r *GenericRepository[T]) ProjectSlice() (interface{}, error) {
-	if r.lastError != nil {
-		return nil, r.lastError
-	}
-
-	if r.currentSlice == nil {
-		return nil, fmt.Errorf("no slice result available for conversion")
-	}
-
-	if r.projection == nil {
-		return nil, fmt.Errorf("no projection configured - use ProjectToDTO() first")
-	}
-
-	if len(*r.currentSlice) == 0 {
-		dtoType := reflect.TypeOf(r.projection)
-		if dtoType.Kind() == reflect.Ptr {
-			dtoType = dtoType.Elem()
-		}
-		sliceType := reflect.SliceOf(dtoType)
-		return reflect.MakeSlice(sliceType, 0, 0).Interface(), nil
-	}
-
-	dtoType := reflect.TypeOf(r.projection)
-	if dtoType.Kind() == reflect.Ptr {
-		dtoType = dtoType.Elem()
-	}
-
-	sliceType := reflect.SliceOf(dtoType)
-	resultSlice := reflect.MakeSlice(sliceType, 0, len(*r.currentSlice))
-
-	for _, entity := range *r.currentSlice {
-		dto, err := mapEntityToDTO(&entity, r.projection)
-		if err != nil {
-			return nil, fmt.Errorf("error converting entity: %w", err)
-		}
-
-		dtoValue := reflect.ValueOf(dto)
-		if dtoValue.Kind() == reflect.Ptr {
-			dtoValue = dtoValue.Elem()
-		}
-		resultSlice = reflect.Append(resultSlice, dtoValue)
-	}
-
-	return resultSlice.Interface(), nil
-}
+package gormrepo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spirandev/go-gormrepo/gormrepo/internal/pkhelper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *GenericRepository[T]) Begin() (*gorm.DB, error) {
+	return r.db.Begin(), nil
+}
+
+func (r *GenericRepository[T]) Commit(tx *gorm.DB) error {
+	return tx.Commit().Error
+}
+
+func (r *GenericRepository[T]) Rollback(tx *gorm.DB) error {
+	return tx.Rollback().Error
+}
+
+func (r *GenericRepository[T]) singleResult() (*T, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	first := func(tx *gorm.DB) *gorm.DB { return tx.First(new(T)) }
+	entity, err := memoized(r, r.db, first, func() (T, error) {
+		var entity T
+		err := r.run(OpQuery, nil, func() error {
+			return r.redacted(r.readDB(r.db)).First(&entity).Error
+		})
+		if err == nil {
+			r.afterLoad(&entity)
+		}
+		return entity, err
+	})
+	return &entity, err
+}
+
+func (r *GenericRepository[T]) listResult() (*[]T, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if err := r.checkOffset(currentOffset(r.db)); err != nil {
+		return nil, err
+	}
+
+	query := r.limitedQuery()
+	find := func(tx *gorm.DB) *gorm.DB { return tx.Find(new([]T)) }
+	entities, err := memoized(r, query, find, func() ([]T, error) {
+		var entities []T
+		err := r.run(OpQuery, nil, func() error {
+			return r.redacted(r.readDB(query)).Find(&entities).Error
+		})
+		if err == nil {
+			r.afterLoad(pointersTo(entities)...)
+		}
+		return entities, err
+	})
+	if err != nil {
+		return &entities, err
+	}
+	if err := r.checkMaxRows(len(entities)); err != nil {
+		return nil, err
+	}
+	entities = slices.Clone(entities)
+	return &entities, nil
+}
+
+// Deprecated: use Exec().Create, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) Create(entity *T) *GenericRepository[T] {
+	return r.keep(r.Exec().Create(entity))
+}
+
+func (r *GenericRepository[T]) CreateWithPreload(entity *T, associations ...string) *GenericRepository[T] {
+	if err := r.prepare(OpCreate, entity); err != nil {
+		r.lastError = err
+		return r
+	}
+
+	err := r.write(OpCreate, entity, func(db *gorm.DB) error {
+		return db.Create(entity).Error
+	})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
+	if err != nil {
+		r.currentResult = entity
+		return r
+	}
+
+	var createdEntity T
+	query := r.db
+
+	for _, association := range associations {
+		query = query.Preload(association)
+	}
+
+	if err := query.First(&createdEntity, fmt.Sprintf("%s = ?", pkName), pkValue).Error; err != nil {
+		r.currentResult = entity
+		return r
+	}
+
+	r.afterLoad(&createdEntity)
+	r.currentResult = &createdEntity
+	return r
+}
+
+func (r *GenericRepository[T]) CreateWithAllAssociations(entity *T) *GenericRepository[T] {
+	if err := r.prepare(OpCreate, entity); err != nil {
+		r.lastError = err
+		return r
+	}
+
+	err := r.write(OpCreate, entity, func(db *gorm.DB) error {
+		return db.Create(entity).Error
+	})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	pkName, pkValue, err := pkhelper.GetPrimaryKey(entity)
+	if err != nil {
+		r.currentResult = entity
+		return r
+	}
+
+	var createdEntity T
+	if err := r.db.Preload(clause.Associations).First(&createdEntity, fmt.Sprintf("%s = ?", pkName), pkValue).Error; err != nil {
+		r.currentResult = entity
+		return r
+	}
+
+	r.afterLoad(&createdEntity)
+	r.currentResult = &createdEntity
+	return r
+}
+
+// Deprecated: use Exec().CreateBatch, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) CreateBatch(entities *[]T) *GenericRepository[T] {
+	return r.keep(r.Exec().CreateBatch(entities))
+}
+
+// Deprecated: use Exec().Update, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) Update(entity *T) *GenericRepository[T] {
+	return r.keep(r.Exec().Update(entity))
+}
+
+// Deprecated: use Exec().UpdateWithPreload, which returns the outcome
+// instead of keeping it in the repository.
+func (r *GenericRepository[T]) UpdateWithPreload(entity *T, associations ...string) *GenericRepository[T] {
+	return r.keep(r.Exec().UpdateWithPreload(entity, associations...))
+}
+
+// Deprecated: use Exec().UpdateFields, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) UpdateFields(entity *T, fields map[string]interface{}) *GenericRepository[T] {
+	return r.keep(r.Exec().UpdateFields(entity, fields))
+}
+
+// UpdateBatchFields applies a different set of column values to each row.
+// Rows sharing the same columns are updated with a single CASE statement and
+// all statements run in one transaction.
+func (r *GenericRepository[T]) UpdateBatchFields(updates map[int64]map[string]interface{}) *GenericRepository[T] {
+	if r.lastError != nil {
+		return r
+	}
+	if len(updates) == 0 {
+		return r
+	}
+
+	for _, fields := range updates {
+		if err := validateEnumFields(r.db, new(T), fields); err != nil {
+			r.lastError = err
+			return r
+		}
+	}
+
+	pkColumn := r.primaryKeyColumn()
+
+	groups := make(map[string][]int64)
+	for id, fields := range updates {
+		columns := make([]string, 0, len(fields))
+		for column := range fields {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		key := strings.Join(columns, ",")
+		groups[key] = append(groups[key], id)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	updateGroups := func(tx *gorm.DB) error {
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+
+			ids := groups[key]
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+			assignments := make(map[string]interface{})
+			for _, column := range strings.Split(key, ",") {
+				var sql strings.Builder
+				args := []interface{}{clause.Column{Name: pkColumn}}
+				sql.WriteString("CASE ?")
+				for _, id := range ids {
+					sql.WriteString(" WHEN ? THEN ?")
+					args = append(args, id, updates[id][column])
+				}
+				sql.WriteString(" ELSE ? END")
+				args = append(args, clause.Column{Name: column})
+				assignments[column] = gorm.Expr(sql.String(), args...)
+			}
+
+			// The condition of the operation already limits tx to the rows of updates
+			query := r.untouched(tx, assignments).Model(new(T))
+			if len(ids) < len(updates) {
+				query = query.Where(clause.IN{Column: clause.Column{Name: pkColumn}, Values: toInterfaces(ids)})
+			}
+			if err := query.Updates(assignments).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Middleware sees the rows updated as the condition of the operation
+	ids := make([]int64, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	err := r.writeWhere(OpUpdate, clause.IN{Column: clause.Column{Name: pkColumn}, Values: toInterfaces(ids)}, func(db *gorm.DB) error {
+		return db.Transaction(updateGroups)
+	})
+	if err == nil {
+		err = r.onCommit(func() error {
+			for _, id := range ids {
+				r.uncacheID(id)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		r.lastError = err
+	}
+	return r
+}
+
+func getID(entity interface{}) interface{} {
+	val := reflect.ValueOf(entity).Elem()
+	return val.FieldByName("ID").Interface()
+}
+
+func (r *GenericRepository[T]) primaryKeyColumn() string {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err == nil && stmt.Schema.PrioritizedPrimaryField != nil {
+		return stmt.Schema.PrioritizedPrimaryField.DBName
+	}
+	return "id"
+}
+
+func toInterfaces[V any](values []V) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// Deprecated: use Exec().Delete, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) Delete(id int64) *GenericRepository[T] {
+	return r.keep(r.Exec().Delete(id))
+}
+
+// Deprecated: use Exec().DeleteEntity, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) DeleteEntity(entity *T) *GenericRepository[T] {
+	return r.keep(r.Exec().DeleteEntity(entity))
+}
+
+func (r *GenericRepository[T]) DeleteBatch(entities *[]T) *GenericRepository[T] {
+	err := r.write(OpDelete, entities, func(db *gorm.DB) error {
+		return db.Delete(entities).Error
+	})
+	if err != nil {
+		r.lastError = err
+	}
+	return r
+}
+
+func (r *GenericRepository[T]) FindByID(id int64) *GenericRepository[T] {
+	return r.Where("id = ?", id)
+}
+
+func (r *GenericRepository[T]) FindFirst() *GenericRepository[T] {
+	return r.Limit(1)
+}
+
+func (r *GenericRepository[T]) FindAll() *GenericRepository[T] {
+	// No need to do anything here, just return the repository
+	// The query will be executed when First(), Get() or One() are called
+	return r
+}
+
+func (r *GenericRepository[T]) Preload(associations ...string) *GenericRepository[T] {
+	for _, association := range associations {
+		if r.joinLoadable(association) {
+			r.db = r.db.Joins(association)
+			continue
+		}
+		r.db = r.db.Preload(association)
+	}
+	return r
+}
+
+func (r *GenericRepository[T]) WithJoins(joins ...string) *GenericRepository[T] {
+	for _, join := range joins {
+		r.db = r.db.Joins(join)
+	}
+	return r
+}
+
+func (r *GenericRepository[T]) InnerJoin(table interface{}, on string, args ...interface{}) *GenericRepository[T] {
+	return r.join("INNER", table, on, args...)
+}
+
+func (r *GenericRepository[T]) LeftJoin(table interface{}, on string, args ...interface{}) *GenericRepository[T] {
+	return r.join("LEFT", table, on, args...)
+}
+
+func (r *GenericRepository[T]) RightJoin(table interface{}, on string, args ...interface{}) *GenericRepository[T] {
+	return r.join("RIGHT", table, on, args...)
+}
+
+// join accepts either a table name or a model whose table is resolved from its schema.
+func (r *GenericRepository[T]) join(joinType string, table interface{}, on string, args ...interface{}) *GenericRepository[T] {
+	tableName, ok := table.(string)
+	if !ok {
+		s, err := parseSchema(r.db, table)
+		if err != nil {
+			r.lastError = err
+			return r
+		}
+		tableName = s.Table
+	}
+
+	r.db = r.db.Joins(fmt.Sprintf("%s JOIN %s ON %s", joinType, quoteJoinTable(r.db.Statement, tableName), on), args...)
+	return r
+}
+
+// quoteJoinTable quotes a table name with an optional alias, e.g. "teams t"
+// or "teams AS t". Anything else, such as a subquery or a name already
+// quoted, is written as is.
+func quoteJoinTable(stmt *gorm.Statement, name string) string {
+	if strings.ContainsAny(name, "(`\"[") {
+		return name
+	}
+	switch fields := strings.Fields(name); {
+	case len(fields) == 1:
+		return stmt.Quote(clause.Table{Name: fields[0]})
+	case len(fields) == 2:
+		return stmt.Quote(clause.Table{Name: fields[0], Alias: fields[1]})
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		return stmt.Quote(clause.Table{Name: fields[0], Alias: fields[2]})
+	}
+	return name
+}
+
+// JoinAssociation LEFT JOINs a belongs-to/has-one association by field name,
+// optionally restricted by extra conditions on the joined table.
+func (r *GenericRepository[T]) JoinAssociation(association string, conds ...interface{}) *GenericRepository[T] {
+	if len(conds) == 0 {
+		r.db = r.db.Joins(association)
+		return r
+	}
+	r.db = r.db.Joins(association, r.db.Session(&gorm.Session{NewDB: true}).Where(conds[0], conds[1:]...))
+	return r
+}
+
+func (r *GenericRepository[T]) InnerJoinAssociation(association string, conds ...interface{}) *GenericRepository[T] {
+	if len(conds) == 0 {
+		r.db = r.db.InnerJoins(association)
+		return r
+	}
+	r.db = r.db.InnerJoins(association, r.db.Session(&gorm.Session{NewDB: true}).Where(conds[0], conds[1:]...))
+	return r
+}
+
+func (r *GenericRepository[T]) Where(query interface{}, args ...interface{}) *GenericRepository[T] {
+	r.db = r.db.Where(query, args...)
+	return r
+}
+
+// WhereAny adds a parenthesized group matching rows where any of the filters holds.
+func (r *GenericRepository[T]) WhereAny(filters map[string]interface{}) *GenericRepository[T] {
+	if len(filters) == 0 {
+		return r
+	}
+
+	group := r.db.Session(&gorm.Session{NewDB: true})
+	for _, k := range filterKeys(filters) {
+		query, args := r.filterCondition(group, k, filters[k])
+		group = group.Or(query, args...)
+	}
+
+	r.db = r.db.Where(group)
+	return r
+}
+
+// WhereGroup ANDs the conditions built by fn as one parenthesized group, e.g.
+// WhereGroup(func(g) { return g.Where("a = ?", 1).Or("b = ?", 2) }).
+func (r *GenericRepository[T]) WhereGroup(fn func(g *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T] {
+	group := fn(r.clone(r.db.Session(&gorm.Session{NewDB: true})))
+	r.db = r.db.Where(group.db)
+	return r
+}
+
+// OrGroup ORs the conditions built by fn as one parenthesized group.
+func (r *GenericRepository[T]) OrGroup(fn func(g *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T] {
+	group := fn(r.clone(r.db.Session(&gorm.Session{NewDB: true})))
+	r.db = r.db.Or(group.db)
+	return r
+}
+
+func (r *GenericRepository[T]) Scopes(fns ...func(*gorm.DB) *gorm.DB) *GenericRepository[T] {
+	r.db = r.db.Scopes(fns...)
+	return r
+}
+
+func (r *GenericRepository[T]) When(cond bool, fn func(r *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T] {
+	if !cond {
+		return r
+	}
+	return fn(r)
+}
+
+func (r *GenericRepository[T]) Unless(cond bool, fn func(r *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T] {
+	return r.When(!cond, fn)
+}
+
+func (r *GenericRepository[T]) Order(value interface{}) *GenericRepository[T] {
+	r.db = r.db.Order(value)
+	return r
+}
+
+func (r *GenericRepository[T]) Count(filters map[string]interface{}) (int64, error) {
+	if r.lastError != nil {
+		return 0, r.lastError
+	}
+	filterRepo := r.clone(r.whereFilters(r.db.Model(new(T)), filters))
+	var count int64
+	err := r.run(OpCount, nil, func() error {
+		return r.readDB(filterRepo.db).Count(&count).Error
+	})
+	return count, err
+}
+
+func (r *GenericRepository[T]) Exists(filters map[string]interface{}) (bool, error) {
+	count, err := r.Count(filters)
+	return count > 0, err
+}
+
+func (r *GenericRepository[T]) CreateWithContext(ctx context.Context, entity *T) *GenericRepository[T] {
+	return r.clone(r.db.WithContext(ctx)).Create(entity)
+}
+
+func (r *GenericRepository[T]) FindByIDWithContext(ctx context.Context, id int64) *GenericRepository[T] {
+	return r.clone(r.db.WithContext(ctx)).Where("id = ?", id)
+}
+
+func (r *GenericRepository[T]) FindOne(filters map[string]interface{}) *GenericRepository[T] {
+	// Apply filters to the existing db (which may already have preloads/joins configured)
+	query := r.whereFilters(r.db, filters)
+
+	// Update the db to preserve the configuration for the next operations
+	r.db = query
+
+	// Execute query and store result for chaining
+	var entity T
+	err := r.run(OpQuery, nil, func() error {
+		return r.redacted(r.readDB(r.db)).First(&entity).Error
+	})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	r.afterLoad(&entity)
+	r.currentResult = &entity
+	return r
+}
+
+func (r *GenericRepository[T]) Limit(limit int) *GenericRepository[T] {
+	r.db = r.db.Limit(limit)
+	return r
+}
+
+func (r *GenericRepository[T]) Offset(offset int) *GenericRepository[T] {
+	r.db = r.db.Offset(offset)
+	return r
+}
+
+func (r *GenericRepository[T]) Paginate(page, pageSize int) *GenericRepository[T] {
+	offset := (page - 1) * pageSize
+	return r.Offset(offset).Limit(pageSize)
+}
+
+func (r *GenericRepository[T]) Transaction(fn func(tx *GenericRepository[T]) error) error {
+	if r.readOptimized {
+		return ErrTransactionsUnsupported
+	}
+	txRepo := r.newTxRepository()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		txRepo.db = tx
+		return fn(txRepo)
+	})
+	if err != nil {
+		return r.afterRollback(txRepo, err)
+	}
+	return r.afterCommit(txRepo)
+}
+
+// WithDB returns a repository with the same configuration on db. The
+// projection is applied to db again; the conditions of r's chain are not.
+func (r *GenericRepository[T]) WithDB(db *gorm.DB) *GenericRepository[T] {
+	c := r.clone(db)
+	switch r.projectionMode {
+	case "partial":
+		return c.ProjectToPartial(r.projection)
+	case "dto":
+		return c.ProjectToDTO(r.projection)
+	}
+	return c
+}
+
+func (r *GenericRepository[T]) Select(query interface{}, args ...interface{}) *GenericRepository[T] {
+	r.db = r.db.Select(query, args...)
+	return r
+}
+
+func (r *GenericRepository[T]) Group(name string) *GenericRepository[T] {
+	r.db = r.db.Group(name)
+	return r
+}
+
+func (r *GenericRepository[T]) Having(query interface{}, args ...interface{}) *GenericRepository[T] {
+	r.db = r.db.Having(query, args...)
+	return r
+}
+
+func (r *GenericRepository[T]) Or(query interface{}, args ...interface{}) *GenericRepository[T] {
+	r.db = r.db.Or(query, args...)
+	return r
+}
+
+func (r *GenericRepository[T]) Not(query interface{}, args ...interface{}) *GenericRepository[T] {
+	r.db = r.db.Not(query, args...)
+	return r
+}
+
+func (r *GenericRepository[T]) First() (*T, error) {
+	return r.singleResult()
+}
+
+func (r *GenericRepository[T]) Get() (*[]T, error) {
+	return r.listResult()
+}
+
+func (r *GenericRepository[T]) One() (*T, error) {
+	r.db = r.db.Limit(1)
+	return r.singleResult()
+}
+
+// ScanInto executes the current chain and scans the rows into dest, which can
+// be any struct, slice of structs or map shaped after the selected columns.
+func (r *GenericRepository[T]) ScanInto(dest interface{}) error {
+	if r.lastError != nil {
+		return r.lastError
+	}
+	return r.run(OpQuery, nil, func() error {
+		return r.redacted(r.readDB(r.db.Model(new(T)))).Scan(dest).Error
+	})
+}
+
+func ScanAs[D, T any](repo *GenericRepository[T]) (*[]D, error) {
+	var results []D
+	if err := repo.ScanInto(&results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// WithTagPriority sets the struct tags DTO fields are mapped to columns
+// with, in order, e.g. WithTagPriority("dto", "json", "gorm"). Recognized
+// tags are dto, projection, json, gorm and other encoding-style tags.
+func (r *GenericRepository[T]) WithTagPriority(tags ...string) *GenericRepository[T] {
+	r.tagPriority = tags
+	return r
+}
+
+func (r *GenericRepository[T]) ProjectToDTO(dtoInterface interface{}) *GenericRepository[T] {
+	newRepo := r.clone(r.db)
+	newRepo.projection = dtoInterface
+	newRepo.projectionMode = "dto"
+	newRepo.currentResult = r.currentResult
+	newRepo.currentSlice = r.currentSlice
+	newRepo.lastError = r.lastError
+	if hasStructFields(dtoInterface) {
+		preloads := extractPreloadsFromDTO(dtoInterface)
+
+		for _, preload := range preloads {
+			newRepo.db = newRepo.db.Preload(preload)
+		}
+	} else {
+		fields := createProjectionFromDTO(dtoInterface, r.tagPriority)
+		if len(fields) > 0 {
+			selectFields := strings.Join(fields, ", ")
+			newRepo.db = newRepo.db.Select(selectFields)
+		}
+	}
+
+	return newRepo
+}
+
+func (r *GenericRepository[T]) Project() (interface{}, error) {
+	if r.projection == nil {
+		return nil, fmt.Errorf("no projection configured - use ProjectToDTO() first")
+	}
+
+	if r.currentResult == nil {
+		return nil, fmt.Errorf("no current result available - execute a query first (FindOne, FindByID, etc.)")
+	}
+
+	return mapEntityToDTO(r.currentResult, r.projection, r.tagPriority)
+}
+
+func (r *GenericRepository[T]) HasError() bool {
+	return r.lastError != nil
+}
+
+func (r *GenericRepository[T]) Error() error {
+	return r.lastError
+}
+
+func (r *GenericRepository[T]) Result() (*T, error) {
+	return r.currentResult, r.lastError
+}
+
+func (r *GenericRepository[T]) Results() (*[]T, error) {
+	return r.currentSlice, r.lastError
+}
+
+func (r *GenericRepository[T]) Execute() error {
+	return r.lastError
+}
+
+func (r *GenericRepository[T]) ProjectEntity(entity *T, dtoInterface interface{}) (interface{}, error) {
+	if entity == nil {
+		return nil, fmt.Errorf("entity cannot be nil")
+	}
+
+	return mapEntityToDTO(entity, dtoInterface, r.tagPriority)
+}
+
+func (r *GenericRepository[T]) ProjectEntitySlice(entities *[]T, dtoInterface interface{}) (interface{}, error) {
+	if entities == nil {
+		return nil, fmt.Errorf("entity slice cannot be nil")
+	}
+
+	if len(*entities) == 0 {
+		dtoType := reflect.TypeOf(dtoInterface)
+		if dtoType.Kind() == reflect.Ptr {
+			dtoType = dtoType.Elem()
+		}
+		sliceType := reflect.SliceOf(dtoType)
+		return reflect.MakeSlice(sliceType, 0, 0).Interface(), nil
+	}
+
+	dtoType := reflect.TypeOf(dtoInterface)
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	sliceType := reflect.SliceOf(dtoType)
+	resultSlice := reflect.MakeSlice(sliceType, 0, len(*entities))
+
+	for _, entity := range *entities {
+		dto, err := mapEntityToDTO(&entity, dtoInterface, r.tagPriority)
+		if err != nil {
+			return nil, fmt.Errorf("error converting entity: %w", err)
+		}
+
+		dtoValue := reflect.ValueOf(dto)
+		if dtoValue.Kind() == reflect.Ptr {
+			dtoValue = dtoValue.Elem()
+		}
+		resultSlice = reflect.Append(resultSlice, dtoValue)
+	}
+
+	return resultSlice.Interface(), nil
+}
+
+func (r *GenericRepository[T]) ProjectSlice() (interface{}, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+
+	if r.currentSlice == nil {
+		return nil, fmt.Errorf("no slice result available for conversion")
+	}
+
+	if r.projection == nil {
+		return nil, fmt.Errorf("no projection configured - use ProjectToDTO() first")
+	}
+
+	if len(*r.currentSlice) == 0 {
+		dtoType := reflect.TypeOf(r.projection)
+		if dtoType.Kind() == reflect.Ptr {
+			dtoType = dtoType.Elem()
+		}
+		sliceType := reflect.SliceOf(dtoType)
+		return reflect.MakeSlice(sliceType, 0, 0).Interface(), nil
+	}
+
+	dtoType := reflect.TypeOf(r.projection)
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	sliceType := reflect.SliceOf(dtoType)
+	resultSlice := reflect.MakeSlice(sliceType, 0, len(*r.currentSlice))
+
+	for _, entity := range *r.currentSlice {
+		dto, err := mapEntityToDTO(&entity, r.projection, r.tagPriority)
+		if err != nil {
+			return nil, fmt.Errorf("error converting entity: %w", err)
+		}
+
+		dtoValue := reflect.ValueOf(dto)
+		if dtoValue.Kind() == reflect.Ptr {
+			dtoValue = dtoValue.Elem()
+		}
+		resultSlice = reflect.Append(resultSlice, dtoValue)
+	}
+
+	return resultSlice.Interface(), nil
+}
@@ -0,0 +1,171 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTooManyRows    = errors.New("too many rows")
+	ErrUnboundedWrite = errors.New("write without WHERE conditions")
+	ErrDeepPagination = errors.New("pagination offset too deep")
+)
+
+type DeepPaginationError struct {
+	Offset    int
+	MaxOffset int
+}
+
+func (e *DeepPaginationError) Error() string {
+	return fmt.Sprintf("offset %d exceeds %d; use cursor pagination (Connection) instead", e.Offset, e.MaxOffset)
+}
+
+func (e *DeepPaginationError) Is(target error) bool {
+	return target == ErrDeepPagination
+}
+
+// WithMaxRows makes Get fail with ErrTooManyRows instead of loading more than n rows.
+func (r *GenericRepository[T]) WithMaxRows(n int) *GenericRepository[T] {
+	r.maxRows = n
+	return r
+}
+
+// WithDefaultLimit applies LIMIT n to list queries that don't set their own limit.
+func (r *GenericRepository[T]) WithDefaultLimit(n int) *GenericRepository[T] {
+	r.defaultLimit = n
+	return r
+}
+
+// WithMaxOffset makes list queries fail with a DeepPaginationError when
+// they skip more than n rows, as the database still reads every skipped row.
+func (r *GenericRepository[T]) WithMaxOffset(n int) *GenericRepository[T] {
+	r.maxOffset = n
+	return r
+}
+
+// StrictWrites makes UpdateWhere and DeleteWhere fail with ErrUnboundedWrite
+// when the chain has no conditions of its own. Unlike gorm's global update
+// check, conditions added implicitly (such as soft delete) don't count.
+func (r *GenericRepository[T]) StrictWrites() *GenericRepository[T] {
+	r.strictWrites = true
+	return r
+}
+
+// UpdateWhere updates the given columns on every row matching the chain conditions.
+func (r *GenericRepository[T]) UpdateWhere(fields map[string]interface{}) *GenericRepository[T] {
+	if r.lastError != nil {
+		return r
+	}
+	if err := r.checkBoundedWrite(); err != nil {
+		r.lastError = err
+		return r
+	}
+	if err := validateEnumFields(r.db, new(T), fields); err != nil {
+		r.lastError = err
+		return r
+	}
+
+	err := r.write(OpUpdate, nil, func(db *gorm.DB) error {
+		return r.untouched(db, fields).Model(new(T)).Updates(r.utcFields(fields)).Error
+	})
+	if err != nil {
+		r.lastError = err
+	}
+	return r
+}
+
+// DeleteWhere deletes every row matching the chain conditions.
+func (r *GenericRepository[T]) DeleteWhere() *GenericRepository[T] {
+	if r.lastError != nil {
+		return r
+	}
+	if err := r.checkBoundedWrite(); err != nil {
+		r.lastError = err
+		return r
+	}
+
+	err := r.write(OpDelete, nil, func(db *gorm.DB) error {
+		return db.Delete(new(T)).Error
+	})
+	if err != nil {
+		r.lastError = err
+	}
+	return r
+}
+
+func (r *GenericRepository[T]) checkBoundedWrite() error {
+	if r.strictWrites && !hasWhereConditions(r.db) {
+		return ErrUnboundedWrite
+	}
+	return nil
+}
+
+func hasWhereConditions(db *gorm.DB) bool {
+	c, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return false
+	}
+	where, ok := c.Expression.(clause.Where)
+	return ok && len(where.Exprs) > 0
+}
+
+func (r *GenericRepository[T]) limitedQuery() *gorm.DB {
+	db := r.orderedQuery(r.db)
+	if paged(db) {
+		db = r.tieBroken(db)
+	}
+
+	limit, hasLimit := currentLimit(db)
+	if !hasLimit && r.defaultLimit > 0 {
+		db = db.Limit(r.defaultLimit)
+		limit, hasLimit = r.defaultLimit, true
+	}
+
+	// One extra row is enough to detect that the cap was exceeded
+	if r.maxRows > 0 && (!hasLimit || limit > r.maxRows) {
+		db = db.Limit(r.maxRows + 1)
+	}
+
+	return unorderedPage(db)
+}
+
+func (r *GenericRepository[T]) checkMaxRows(count int) error {
+	if r.maxRows > 0 && count > r.maxRows {
+		return fmt.Errorf("%w: query returned more than %d rows", ErrTooManyRows, r.maxRows)
+	}
+	return nil
+}
+
+func (r *GenericRepository[T]) checkOffset(offset int) error {
+	if r.maxOffset > 0 && offset > r.maxOffset {
+		return &DeepPaginationError{Offset: offset, MaxOffset: r.maxOffset}
+	}
+	return nil
+}
+
+func currentOffset(db *gorm.DB) int {
+	c, ok := db.Statement.Clauses["LIMIT"]
+	if !ok {
+		return 0
+	}
+	limit, ok := c.Expression.(clause.Limit)
+	if !ok {
+		return 0
+	}
+	return limit.Offset
+}
+
+func currentLimit(db *gorm.DB) (int, bool) {
+	c, ok := db.Statement.Clauses["LIMIT"]
+	if !ok {
+		return 0, false
+	}
+	limit, ok := c.Expression.(clause.Limit)
+	if !ok || limit.Limit == nil || *limit.Limit < 0 {
+		return 0, false
+	}
+	return *limit.Limit, true
+}
@@ -0,0 +1,110 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// Change is a snapshot of an entity written inside a transaction.
+type Change[T any] struct {
+	Kind   OperationKind
+	Entity T
+}
+
+// CommitHook receives the changes of a transaction once its outcome is known.
+// Outside a transaction every write is its own transaction.
+type CommitHook[T any] func(ctx context.Context, changes []Change[T]) error
+
+// pendingWork is what a transaction defers until it commits.
+type pendingWork[T any] struct {
+	events     []any
+	changes    []Change[T]
+	operations []loggedOperations
+	callbacks  []func() error
+	uncached   []string // Cache keys of the rows written
+}
+
+// add appends the work of other to w.
+func (w *pendingWork[T]) add(other *pendingWork[T]) {
+	w.events = append(w.events, other.events...)
+	w.changes = append(w.changes, other.changes...)
+	w.operations = append(w.operations, other.operations...)
+	w.callbacks = append(w.callbacks, other.callbacks...)
+	w.uncached = append(w.uncached, other.uncached...)
+}
+
+// onCommit runs fn once the writes made so far are committed, when the
+// surrounding transaction commits if there is one. Its error is returned as
+// a committedError.
+func (r *GenericRepository[T]) onCommit(fn func() error) error {
+	if r.pending != nil {
+		r.pending.callbacks = append(r.pending.callbacks, fn)
+		return nil
+	}
+	if err := fn(); err != nil {
+		return &committedError{err}
+	}
+	return nil
+}
+
+// AfterCommit registers hooks run after the outermost transaction commits,
+// the safe place to call external systems.
+func (r *GenericRepository[T]) AfterCommit(hooks ...CommitHook[T]) *GenericRepository[T] {
+	r.afterCommitHooks = append(r.afterCommitHooks, hooks...)
+	return r
+}
+
+// AfterRollback registers hooks run with the changes a rolled back transaction discarded.
+func (r *GenericRepository[T]) AfterRollback(hooks ...CommitHook[T]) *GenericRepository[T] {
+	r.afterRollbackHooks = append(r.afterRollbackHooks, hooks...)
+	return r
+}
+
+func (r *GenericRepository[T]) snapshot(kind OperationKind, target any) []Change[T] {
+	if len(r.afterCommitHooks) == 0 && len(r.afterRollbackHooks) == 0 {
+		return nil
+	}
+
+	var changes []Change[T]
+	forEachEntity(target, func(entity reflect.Value) error {
+		if snapshot, ok := entity.Interface().(T); ok {
+			changes = append(changes, Change[T]{Kind: kind, Entity: snapshot})
+		}
+		return nil
+	})
+	return changes
+}
+
+// committed runs the work deferred until the data was committed.
+func (r *GenericRepository[T]) committed(work *pendingWork[T]) error {
+	r.recordPosition()
+	r.dropCached(work.uncached)
+	var errs []error
+	for _, callback := range work.callbacks {
+		errs = append(errs, callback())
+	}
+	errs = append(errs, writeOperations(work.operations), r.dispatchEvents(work.events))
+	errs = append(errs, runCommitHooks(r.context(), r.afterCommitHooks, work.changes)...)
+	return errors.Join(errs...)
+}
+
+func (r *GenericRepository[T]) rolledBack(work *pendingWork[T]) error {
+	// Reads inside the transaction may have cached the rows it wrote
+	r.dropCached(work.uncached)
+	return errors.Join(runCommitHooks(r.context(), r.afterRollbackHooks, work.changes)...)
+}
+
+func runCommitHooks[T any](ctx context.Context, hooks []CommitHook[T], changes []Change[T]) []error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := hook(ctx, changes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,218 @@
+package gormrepo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultLocale is the locale of the values stored in the entity's own
+// columns, which every other locale falls back to.
+var DefaultLocale = "en"
+
+// Translation is a translated value of an i18n field, stored in the sibling
+// table <table>_translations of the entity's table.
+type Translation struct {
+	RecordID string `gorm:"primaryKey;size:191"`
+	Locale   string `gorm:"primaryKey;size:35"`
+	Field    string `gorm:"primaryKey;size:64"`
+	Value    string
+}
+
+type localeKey struct{}
+
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// WithLocale reads and writes the string fields tagged i18n:"true" in
+// locale instead of the locale of the context. Loaded entities get the
+// translation of locale, else of its language ("pt" for "pt-BR"), else the
+// default value. Writes store the fields as translations; updates leave the
+// default values unchanged.
+func (r *GenericRepository[T]) WithLocale(locale string) *GenericRepository[T] {
+	r.locale = locale
+	return r
+}
+
+func (r *GenericRepository[T]) currentLocale() string {
+	if r.locale != "" {
+		return r.locale
+	}
+	return LocaleFromContext(r.context())
+}
+
+func translationTable(s *schema.Schema) string {
+	return s.Table + "_translations"
+}
+
+func i18nFields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if field.Tag.Get("i18n") == "true" && field.DBName != "" && field.FieldType.Kind() == reflect.String {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// localeFallbacks returns locale followed by the less specific locales it
+// falls back to, excluding the default locale.
+func localeFallbacks(locale string) []string {
+	var locales []string
+	for locale != "" && !strings.EqualFold(locale, DefaultLocale) {
+		locales = append(locales, locale)
+		i := strings.LastIndexAny(locale, "-_")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return locales
+}
+
+// translate overlays the translations of the current locale on loaded
+// entities.
+func (r *GenericRepository[T]) translate(entities ...*T) error {
+	locales := localeFallbacks(r.currentLocale())
+	if len(locales) == 0 {
+		return nil
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	fields := i18nFields(s)
+	if len(fields) == 0 || s.PrioritizedPrimaryField == nil {
+		return nil
+	}
+
+	ctx := r.context()
+	byID := map[string][]reflect.Value{}
+	var ids []string
+	for _, entity := range entities {
+		if entity == nil {
+			continue
+		}
+		value := reflect.ValueOf(entity).Elem()
+		id, isZero := s.PrioritizedPrimaryField.ValueOf(ctx, value)
+		if isZero {
+			continue
+		}
+		key := fmt.Sprint(id)
+		if _, ok := byID[key]; !ok {
+			ids = append(ids, key)
+		}
+		byID[key] = append(byID[key], value)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var translations []Translation
+	err = r.db.Session(&gorm.Session{NewDB: true}).
+		Table(translationTable(s)).
+		Where("record_id IN ? AND locale IN ?", ids, locales).
+		Find(&translations).Error
+	if err != nil {
+		return err
+	}
+
+	// Apply the least specific locale first so the most specific one wins
+	rank := map[string]int{}
+	for i, locale := range locales {
+		rank[locale] = len(locales) - i
+	}
+	fieldsByName := map[string]*schema.Field{}
+	for _, field := range fields {
+		fieldsByName[field.DBName] = field
+	}
+	for pass := 1; pass <= len(locales); pass++ {
+		for _, t := range translations {
+			field := fieldsByName[t.Field]
+			if rank[t.Locale] != pass || field == nil {
+				continue
+			}
+			for _, value := range byID[t.RecordID] {
+				if err := field.Set(ctx, value, t.Value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// withTranslations wraps a write of target so its i18n fields are stored as
+// translations of the current locale in the same transaction.
+func (r *GenericRepository[T]) withTranslations(kind OperationKind, target any, op func(db *gorm.DB) error) func(db *gorm.DB) error {
+	if target == nil || (kind != OpCreate && kind != OpUpdate && kind != OpUpsert && kind != OpDelete) {
+		return op
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return func(*gorm.DB) error { return err }
+	}
+	fields := i18nFields(s)
+	locales := localeFallbacks(r.currentLocale())
+	if len(fields) == 0 || s.PrioritizedPrimaryField == nil || (kind != OpDelete && len(locales) == 0) {
+		return op
+	}
+
+	ctx := r.context()
+	return func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if kind == OpUpdate {
+				columns := make([]string, len(fields))
+				for i, field := range fields {
+					columns[i] = field.DBName
+				}
+				tx = tx.Omit(columns...)
+			}
+			if err := op(tx); err != nil {
+				return err
+			}
+
+			table := tx.Session(&gorm.Session{NewDB: true}).Table(translationTable(s))
+			return forEachEntity(target, func(entity reflect.Value) error {
+				id, isZero := s.PrioritizedPrimaryField.ValueOf(ctx, entity)
+				if isZero {
+					return nil
+				}
+				if kind == OpDelete {
+					return table.Where("record_id = ?", fmt.Sprint(id)).Delete(&Translation{}).Error
+				}
+
+				translations := make([]Translation, len(fields))
+				for i, field := range fields {
+					value, _ := field.ValueOf(ctx, entity)
+					translations[i] = Translation{RecordID: fmt.Sprint(id), Locale: locales[0], Field: field.DBName, Value: fmt.Sprint(value)}
+				}
+				return table.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "record_id"}, {Name: "locale"}, {Name: "field"}},
+					DoUpdates: clause.AssignmentColumns([]string{"value"}),
+				}).Create(&translations).Error
+			})
+		})
+	}
+}
+
+// migrateTranslations creates the translation tables of models with i18n
+// fields.
+func migrateTranslations(db *gorm.DB, model any) error {
+	s, err := parseSchema(db, model)
+	if err != nil || len(i18nFields(s)) == 0 {
+		return err
+	}
+	return db.Table(translationTable(s)).AutoMigrate(&Translation{})
+}
@@ -0,0 +1,86 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key already used for a different entity type")
+
+type IdempotencyKey struct {
+	Key        string `gorm:"primaryKey;size:191"`
+	EntityType string `gorm:"size:191"`
+	EntityID   string `gorm:"size:191"`
+	CreatedAt  time.Time
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// CreateIdempotent creates entity unless key was seen before, in which case
+// the entity created by the first call is loaded into the result instead.
+// The key is recorded in the same transaction as the insert.
+func (r *GenericRepository[T]) CreateIdempotent(entity *T, key string) *GenericRepository[T] {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		r.lastError = fmt.Errorf("primary key not found in %s", s.Name)
+		return r
+	}
+
+	var result *T
+	err = r.Transaction(func(tx *GenericRepository[T]) error {
+		record := IdempotencyKey{Key: key, EntityType: s.Table}
+		res := tx.db.Session(&gorm.Session{NewDB: true}).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			var existing IdempotencyKey
+			if err := tx.db.Session(&gorm.Session{NewDB: true}).Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: key}).First(&existing).Error; err != nil {
+				return err
+			}
+			if existing.EntityType != s.Table {
+				return fmt.Errorf("%w: %s", ErrIdempotencyKeyReused, existing.EntityType)
+			}
+
+			var previous T
+			if err := tx.db.Session(&gorm.Session{NewDB: true}).Where(clause.Eq{Column: clause.Column{Name: pk.DBName}, Value: existing.EntityID}).First(&previous).Error; err != nil {
+				return err
+			}
+			r.afterLoad(&previous)
+			result = &previous
+			return nil
+		}
+
+		if err := tx.Create(entity).Error(); err != nil {
+			return err
+		}
+
+		pkValue, _ := pk.ValueOf(r.context(), reflect.ValueOf(entity).Elem())
+		record.EntityID = fmt.Sprint(pkValue)
+		if err := tx.db.Session(&gorm.Session{NewDB: true}).Model(&record).Update("entity_id", record.EntityID).Error; err != nil {
+			return err
+		}
+		result = entity
+		return nil
+	})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	r.currentResult = result
+	return r
+}
@@ -0,0 +1,353 @@
+package gormrepo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type IDGenerator interface {
+	NewID() string
+}
+
+type IDGeneratorFunc func() string
+
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+var (
+	UUIDv7Generator IDGenerator = IDGeneratorFunc(NewUUIDv7)
+	ULIDGenerator   IDGenerator = IDGeneratorFunc(NewULID)
+)
+
+// WithIDGenerator assigns generated IDs to empty string or 16-byte (UUID)
+// primary keys on create. 16-byte keys require a generator returning UUIDs.
+func (r *GenericRepository[T]) WithIDGenerator(generator IDGenerator) *GenericRepository[T] {
+	r.idGenerator = generator
+	return r
+}
+
+func (r *GenericRepository[T]) assignIDs(target any) error {
+	if r.idGenerator == nil {
+		return nil
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return nil
+	}
+
+	return forEachEntity(target, func(entity reflect.Value) error {
+		return r.assignID(pk, entity)
+	})
+}
+
+func (r *GenericRepository[T]) assignID(pk *schema.Field, entity reflect.Value) error {
+	ctx := context.Background()
+	if _, isZero := pk.ValueOf(ctx, entity); !isZero {
+		return nil
+	}
+
+	id := r.idGenerator.NewID()
+	switch {
+	case pk.FieldType.Kind() == reflect.String:
+		return pk.Set(ctx, entity, id)
+	case pk.FieldType.Kind() == reflect.Array && pk.FieldType.Len() == 16 && pk.FieldType.Elem().Kind() == reflect.Uint8:
+		raw, err := hex.DecodeString(strings.ReplaceAll(id, "-", ""))
+		if err != nil || len(raw) != 16 {
+			return fmt.Errorf("generated id %q is not a UUID", id)
+		}
+		value := reflect.New(pk.FieldType).Elem()
+		reflect.Copy(value, reflect.ValueOf(raw))
+		pk.ReflectValueOf(ctx, entity).Set(value)
+		return nil
+	}
+	return nil
+}
+
+// forEachEntity calls fn with every addressable struct in target, which may be
+// a pointer to a struct or to a slice of structs or struct pointers.
+func forEachEntity(target any, fn func(entity reflect.Value) error) error {
+	if target == nil {
+		return nil
+	}
+
+	val := reflect.ValueOf(target)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		return fn(val)
+	case reflect.Slice:
+		for i := 0; i < val.Len(); i++ {
+			elem := val.Index(i)
+			for elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					break
+				}
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			if err := fn(elem); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewUUIDv7 returns a time-ordered RFC 9562 version 7 UUID.
+func NewUUIDv7() string {
+	var b [16]byte
+	rand.Read(b[6:])
+
+	ms := uint64(time.Now().UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a lexicographically sortable ULID.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	// 128 bits encoded as 26 base32 characters, most significant first
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// IDAllocator mints integer primary keys before insert, so services don't
+// depend on a database sequence round trip per row.
+type IDAllocator interface {
+	NextIDs(ctx context.Context, n int) ([]int64, error)
+}
+
+type IDAllocatorFunc func(ctx context.Context, n int) ([]int64, error)
+
+func (f IDAllocatorFunc) NextIDs(ctx context.Context, n int) ([]int64, error) {
+	return f(ctx, n)
+}
+
+// WithIDAllocator assigns allocated IDs to zero integer primary keys on create.
+func (r *GenericRepository[T]) WithIDAllocator(allocator IDAllocator) *GenericRepository[T] {
+	r.idAllocator = allocator
+	return r
+}
+
+func (r *GenericRepository[T]) allocateIDs(target any) error {
+	if r.idAllocator == nil {
+		return nil
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return nil
+	}
+	switch pk.FieldType.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+	default:
+		return nil
+	}
+
+	ctx := context.Background()
+	var pending []reflect.Value
+	forEachEntity(target, func(entity reflect.Value) error {
+		if _, isZero := pk.ValueOf(ctx, entity); isZero {
+			pending = append(pending, entity)
+		}
+		return nil
+	})
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids, err := r.idAllocator.NextIDs(r.context(), len(pending))
+	if err != nil {
+		return fmt.Errorf("error allocating ids: %w", err)
+	}
+	if len(ids) != len(pending) {
+		return fmt.Errorf("id allocator returned %d ids, expected %d", len(ids), len(pending))
+	}
+
+	for i, entity := range pending {
+		if err := pk.Set(ctx, entity, ids[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SnowflakeAllocator generates 64-bit IDs made of a millisecond timestamp,
+// a node number and a per-millisecond sequence.
+type SnowflakeAllocator struct {
+	mu       sync.Mutex
+	epoch    time.Time
+	node     int64
+	lastMs   int64
+	sequence int64
+}
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+var DefaultSnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func NewSnowflakeAllocator(node int64) (*SnowflakeAllocator, error) {
+	if node < 0 || node >= 1<<snowflakeNodeBits {
+		return nil, fmt.Errorf("snowflake node must be between 0 and %d", 1<<snowflakeNodeBits-1)
+	}
+	return &SnowflakeAllocator{epoch: DefaultSnowflakeEpoch, node: node, lastMs: -1}, nil
+}
+
+func (a *SnowflakeAllocator) NextIDs(ctx context.Context, n int) ([]int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ids := make([]int64, 0, n)
+	for len(ids) < n {
+		ms := time.Since(a.epoch).Milliseconds()
+		if ms < a.lastMs {
+			// Clock moved backwards; keep issuing from the last timestamp
+			ms = a.lastMs
+		}
+
+		if ms == a.lastMs {
+			if a.sequence == snowflakeMaxSequence {
+				time.Sleep(time.Millisecond)
+				continue
+			}
+			a.sequence++
+		} else {
+			a.sequence = 0
+		}
+		a.lastMs = ms
+
+		ids = append(ids, ms<<(snowflakeNodeBits+snowflakeSequenceBits)|a.node<<snowflakeSequenceBits|a.sequence)
+	}
+	return ids, nil
+}
+
+type IDBlock struct {
+	Name      string `gorm:"primaryKey;size:191"`
+	NextValue int64
+}
+
+func (IDBlock) TableName() string {
+	return "id_blocks"
+}
+
+// BlockAllocator reserves ranges of IDs from the id_blocks table and hands
+// them out from memory, costing one round trip per block instead of per row.
+type BlockAllocator struct {
+	mu        sync.Mutex
+	db        *gorm.DB
+	name      string
+	blockSize int64
+	next      int64
+	end       int64
+}
+
+func NewBlockAllocator(db *gorm.DB, name string, blockSize int64) *BlockAllocator {
+	if blockSize <= 0 {
+		blockSize = 1000
+	}
+	return &BlockAllocator{db: db, name: name, blockSize: blockSize}
+}
+
+func (a *BlockAllocator) NextIDs(ctx context.Context, n int) ([]int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ids := make([]int64, 0, n)
+	for len(ids) < n {
+		if a.next >= a.end {
+			if err := a.reserve(ctx); err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, a.next)
+		a.next++
+	}
+	return ids, nil
+}
+
+func (a *BlockAllocator) reserve(ctx context.Context) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		advance := func() (int64, error) {
+			res := tx.Model(&IDBlock{}).Where("name = ?", a.name).
+				Update("next_value", gorm.Expr("next_value + ?", a.blockSize))
+			return res.RowsAffected, res.Error
+		}
+		rows, err := advance()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			// Allocators using the name for the first time may race to
+			// create its row; whichever loses advances the winner's
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&IDBlock{Name: a.name, NextValue: 1}).Error
+			if err != nil {
+				return err
+			}
+			if _, err := advance(); err != nil {
+				return err
+			}
+		}
+
+		var block IDBlock
+		if err := tx.Where("name = ?", a.name).First(&block).Error; err != nil {
+			return err
+		}
+		a.next = block.NextValue - a.blockSize
+		a.end = block.NextValue
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package gormrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	postgresSeqScan = regexp.MustCompile(`Seq Scan on (\S+)`)
+	postgresFilter  = regexp.MustCompile(`Filter: (.*)`)
+	sqliteScan      = regexp.MustCompile(`^SCAN (?:TABLE )?(\S+)`)
+	filterColumn    = regexp.MustCompile(`(\w+)\)*(?:::[\w ]+?)?\)* (?:[=<>]|!?~~|IS\b|IN\b)`)
+)
+
+// IndexAdvice is a query found to scan a whole table.
+type IndexAdvice struct {
+	Fingerprint string
+	Table       string
+	Columns     []string // Filtered columns, when the plan reports them
+	Calls       int64
+}
+
+func (a IndexAdvice) String() string {
+	if len(a.Columns) == 0 {
+		return fmt.Sprintf("full scan on %s (%d calls): consider an index", a.Table, a.Calls)
+	}
+	return fmt.Sprintf("sequential scan on %s filtered by %s (%d calls): consider an index",
+		a.Table, strings.Join(a.Columns, ", "), a.Calls)
+}
+
+// IndexAdvisor EXPLAINs each distinct query once and logs the ones that scan
+// a whole table, repeating the advice as their call count grows tenfold. It
+// adds a query per new statement, so enable it in development only, with
+// db.Use(advisor).
+type IndexAdvisor struct {
+	mu      sync.Mutex
+	calls   map[string]int64
+	advice  map[string]*IndexAdvice
+	explain map[string]bool
+}
+
+func NewIndexAdvisor() *IndexAdvisor {
+	return &IndexAdvisor{
+		calls:   make(map[string]int64),
+		advice:  make(map[string]*IndexAdvice),
+		explain: make(map[string]bool),
+	}
+}
+
+func (a *IndexAdvisor) Name() string {
+	return "gormrepo:index_advisor"
+}
+
+func (a *IndexAdvisor) Initialize(db *gorm.DB) error {
+	return db.Callback().Query().After("gorm:query").Register("gormrepo:index_advisor", a.after)
+}
+
+func (a *IndexAdvisor) after(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || db.Statement.SQL.Len() == 0 {
+		return
+	}
+	query := db.Statement.SQL.String()
+	fingerprint := Fingerprint(query)
+
+	a.mu.Lock()
+	a.calls[fingerprint]++
+	calls := a.calls[fingerprint]
+	explained := a.explain[fingerprint]
+	a.explain[fingerprint] = true
+	a.mu.Unlock()
+
+	if !explained {
+		if advice, ok := explainScan(db, query); ok {
+			advice.Fingerprint = fingerprint
+			a.mu.Lock()
+			a.advice[fingerprint] = advice
+			a.mu.Unlock()
+		}
+	}
+
+	a.mu.Lock()
+	advice, ok := a.advice[fingerprint]
+	if ok {
+		advice.Calls = calls
+	}
+	a.mu.Unlock()
+
+	if ok && isPowerOfTen(calls) {
+		db.Logger.Warn(db.Statement.Context, "%s: %s", advice, fingerprint)
+	}
+}
+
+// Advice returns the queries found to scan whole tables, most called first.
+func (a *IndexAdvisor) Advice() []IndexAdvice {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	advice := make([]IndexAdvice, 0, len(a.advice))
+	for _, item := range a.advice {
+		advice = append(advice, *item)
+	}
+	sort.Slice(advice, func(i, j int) bool {
+		return advice[i].Calls > advice[j].Calls
+	})
+	return advice
+}
+
+// explainScan reads the plan of query and reports a full table scan in it.
+func explainScan(db *gorm.DB, query string) (*IndexAdvice, bool) {
+	prefix := "EXPLAIN "
+	if db.Dialector.Name() == "sqlite" {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+
+	// The statement's own connection keeps transactions and placeholders intact
+	rows, err := db.Statement.ConnPool.QueryContext(db.Statement.Context, prefix+query, db.Statement.Vars...)
+	if err != nil {
+		return nil, false
+	}
+	defer rows.Close()
+
+	plan, err := planRows(rows)
+	if err != nil {
+		return nil, false
+	}
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		for i, line := range plan {
+			m := postgresSeqScan.FindStringSubmatch(line["QUERY PLAN"])
+			if m == nil {
+				continue
+			}
+			advice := &IndexAdvice{Table: m[1]}
+			if i+1 < len(plan) {
+				if f := postgresFilter.FindStringSubmatch(plan[i+1]["QUERY PLAN"]); f != nil {
+					advice.Columns = filterColumns(f[1])
+				}
+			}
+			return advice, true
+		}
+	case "mysql":
+		for _, row := range plan {
+			if row["type"] == "ALL" {
+				return &IndexAdvice{Table: row["table"]}, true
+			}
+		}
+	case "sqlite":
+		for _, row := range plan {
+			if m := sqliteScan.FindStringSubmatch(row["detail"]); m != nil {
+				return &IndexAdvice{Table: m[1]}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// planRows reads every row of an EXPLAIN result as column/text pairs.
+func planRows(rows *sql.Rows) ([]map[string]string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []map[string]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			row[column] = values[i].String
+		}
+		plan = append(plan, row)
+	}
+	return plan, rows.Err()
+}
+
+func filterColumns(filter string) []string {
+	var columns []string
+	for _, m := range filterColumn.FindAllStringSubmatch(filter, -1) {
+		if !containsString(columns, m[1]) {
+			columns = append(columns, m[1])
+		}
+	}
+	return columns
+}
+
+func isPowerOfTen(n int64) bool {
+	for n >= 10 && n%10 == 0 {
+		n /= 10
+	}
+	return n == 1
+}
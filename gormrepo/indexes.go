@@ -0,0 +1,123 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrIndexMismatch = errors.New("index does not match its declaration")
+
+// IndexDeclaration is an index EnsureMigrated keeps on a model's table.
+type IndexDeclaration struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+type IndexOption func(*IndexDeclaration)
+
+func Columns(columns ...string) IndexOption {
+	return func(d *IndexDeclaration) {
+		d.Columns = append(d.Columns, columns...)
+	}
+}
+
+func Unique() IndexOption {
+	return func(d *IndexDeclaration) {
+		d.Unique = true
+	}
+}
+
+var indexes = map[reflect.Type][]IndexDeclaration{}
+
+// DeclareIndex declares an index on the table of T and registers T, e.g.
+// DeclareIndex[User]("idx_users_email", Columns("email"), Unique()).
+// Declaring a name again replaces the previous declaration.
+func DeclareIndex[T any](name string, opts ...IndexOption) {
+	decl := IndexDeclaration{Name: name}
+	for _, opt := range opts {
+		opt(&decl)
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	registerModel(typ)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	decls := indexes[typ]
+	for i := range decls {
+		if decls[i].Name == name {
+			decls[i] = decl
+			return
+		}
+	}
+	indexes[typ] = append(decls, decl)
+}
+
+// ensureIndexes creates the declared indexes of model that don't exist yet
+// and checks the columns and uniqueness of those that do.
+func ensureIndexes(db *gorm.DB, model any) error {
+	registryMu.RLock()
+	decls := slices.Clone(indexes[reflect.TypeOf(model).Elem()])
+	registryMu.RUnlock()
+	if len(decls) == 0 {
+		return nil
+	}
+
+	s, err := parseSchema(db, model)
+	if err != nil {
+		return err
+	}
+	migrator := db.Migrator()
+	// Dialects that can't list indexes only get missing ones created
+	existing, listErr := migrator.GetIndexes(model)
+
+	var errs []error
+	for _, decl := range decls {
+		if len(decl.Columns) == 0 {
+			errs = append(errs, fmt.Errorf("index %s declares no columns", decl.Name))
+			continue
+		}
+
+		if listErr != nil {
+			if !migrator.HasIndex(model, decl.Name) {
+				errs = append(errs, createIndex(db, s.Table, decl))
+			}
+			continue
+		}
+		found := slices.IndexFunc(existing, func(idx gorm.Index) bool { return idx.Name() == decl.Name })
+		if found < 0 {
+			errs = append(errs, createIndex(db, s.Table, decl))
+			continue
+		}
+
+		idx := existing[found]
+		unique, _ := idx.Unique()
+		if !slices.Equal(idx.Columns(), decl.Columns) || unique != decl.Unique {
+			errs = append(errs, fmt.Errorf("%w: %s on %s has columns %v (unique %t), declared %v (unique %t)",
+				ErrIndexMismatch, decl.Name, s.Table, idx.Columns(), unique, decl.Columns, decl.Unique))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func createIndex(db *gorm.DB, table string, decl IndexDeclaration) error {
+	unique := ""
+	if decl.Unique {
+		unique = "UNIQUE "
+	}
+	columns := make([]string, len(decl.Columns))
+	vars := []any{clause.Column{Name: decl.Name}, clause.Table{Name: table}}
+	for i, column := range decl.Columns {
+		columns[i] = "?"
+		vars = append(vars, clause.Column{Name: column})
+	}
+	return db.Exec(fmt.Sprintf("CREATE %sINDEX ? ON ? (%s)", unique, strings.Join(columns, ", ")), vars...).Error
+}
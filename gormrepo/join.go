@@ -0,0 +1,120 @@
+package gormrepo
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	joinedLeftPrefix  = "l__"
+	joinedRightPrefix = "r__"
+)
+
+// Joined is one row of a JoinQuery: the repository entity and the joined one.
+type Joined[T, U any] struct {
+	Left  T `gorm:"embedded;embeddedPrefix:l__"`
+	Right U `gorm:"embedded;embeddedPrefix:r__"`
+}
+
+type JoinQuery[T, U any] struct {
+	repo     *GenericRepository[T]
+	joinType string
+	on       string
+	args     []interface{}
+}
+
+// JoinWith joins the table of U to the repository query. The on condition
+// references both tables by name, e.g. JoinWith[Team](users, "teams.id = users.team_id").
+func JoinWith[U, T any](repo *GenericRepository[T], on string, args ...interface{}) *JoinQuery[T, U] {
+	return &JoinQuery[T, U]{repo: repo, joinType: "INNER", on: on, args: args}
+}
+
+func (q *JoinQuery[T, U]) Left() *JoinQuery[T, U] {
+	q.joinType = "LEFT"
+	return q
+}
+
+func (q *JoinQuery[T, U]) Inner() *JoinQuery[T, U] {
+	q.joinType = "INNER"
+	return q
+}
+
+func (q *JoinQuery[T, U]) Get() (*[]Joined[T, U], error) {
+	var results []Joined[T, U]
+	if err := q.Scan(&results); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		q.repo.afterLoad(&results[i].Left)
+		computeFields(&results[i].Right)
+	}
+	return &results, nil
+}
+
+// Scan executes the join and scans into dest, which may be any struct slice
+// whose columns match the selected ones. Columns of T hidden by a column
+// policy are not selected.
+func (q *JoinQuery[T, U]) Scan(dest interface{}) error {
+	r := q.repo
+	if r.lastError != nil {
+		return r.lastError
+	}
+	db := r.db
+
+	left, err := parseSchema(db, new(T))
+	if err != nil {
+		return err
+	}
+	right, err := parseSchema(db, new(U))
+	if err != nil {
+		return err
+	}
+	if left.Table == right.Table {
+		return fmt.Errorf("cannot join %s with itself", left.Table)
+	}
+
+	// Columns of U may share the names of hidden ones, so the hidden columns
+	// are left out here rather than by redacted
+	var hidden []string
+	if r.columnPolicy != nil {
+		hidden = r.columnPolicy.hiddenColumns(r.context(), db, new(T))
+	}
+
+	var selects []string
+	selects = append(selects, aliasedColumns(db, left, joinedLeftPrefix, hidden)...)
+	selects = append(selects, aliasedColumns(db, right, joinedRightPrefix, nil)...)
+
+	join := fmt.Sprintf("%s JOIN %s ON %s", q.joinType, db.Statement.Quote(right.Table), q.on)
+
+	query := db.Session(&gorm.Session{}).Model(new(T)).
+		Select(strings.Join(selects, ", ")).
+		Joins(join, q.args...)
+	return r.run(OpQuery, nil, func() error {
+		return r.readDB(query).Scan(dest).Error
+	})
+}
+
+func parseSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+func aliasedColumns(db *gorm.DB, s *schema.Schema, prefix string, hidden []string) []string {
+	columns := make([]string, 0, len(s.DBNames))
+	for _, name := range s.DBNames {
+		if containsString(hidden, name) {
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s AS %s",
+			db.Statement.Quote(clause.Column{Table: s.Table, Name: name}),
+			db.Statement.Quote(prefix+name)))
+	}
+	return columns
+}
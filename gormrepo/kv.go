@@ -0,0 +1,141 @@
+package gormrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	kvCachePrefix     = "gormrepo:kv:"
+	kvMissCachePrefix = "gormrepo:kv-miss:"
+)
+
+type Setting struct {
+	Key       string `gorm:"primaryKey;size:191"`
+	Value     string `gorm:"type:text"` // JSON encoded
+	UpdatedAt time.Time
+}
+
+func (Setting) TableName() string {
+	return "settings"
+}
+
+// KVRepository stores typed configuration values as JSON in the settings table.
+type KVRepository struct {
+	db       *gorm.DB
+	cache    Cache
+	cacheTTL time.Duration
+	missTTL  time.Duration
+	flights  flightGroup // Collapses concurrent cache misses of a key
+}
+
+func NewKVRepository(db *gorm.DB) *KVRepository {
+	return &KVRepository{db: db}
+}
+
+func (kv *KVRepository) WithCache(cache Cache, ttl time.Duration) *KVRepository {
+	kv.cache = cache
+	kv.cacheTTL = ttl
+	return kv
+}
+
+// WithNegativeCache makes the cache set by WithCache also remember keys
+// found missing for ttl, so repeated lookups of keys that don't exist stay
+// off the database. Keep ttl short: a key created through another node is
+// only seen once its entry expires.
+func (kv *KVRepository) WithNegativeCache(ttl time.Duration) *KVRepository {
+	kv.missTTL = ttl
+	return kv
+}
+
+// GetValue decodes the value stored under key into V. It returns ErrNotFound
+// when the key doesn't exist.
+func GetValue[V any](kv *KVRepository, key string) (V, error) {
+	var value V
+
+	raw, err := kv.raw(key)
+	if err != nil {
+		return value, err
+	}
+
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return value, fmt.Errorf("error decoding setting %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// GetValueOr behaves like GetValue but returns fallback for missing keys.
+func GetValueOr[V any](kv *KVRepository, key string, fallback V) (V, error) {
+	value, err := GetValue[V](kv, key)
+	if errors.Is(err, ErrNotFound) {
+		return fallback, nil
+	}
+	return value, err
+}
+
+func (kv *KVRepository) SetValue(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding setting %s: %w", key, err)
+	}
+
+	setting := &Setting{Key: key, Value: string(encoded)}
+	if err := New[Setting](kv.db).OnConflictColumns("key").UpdateOnly("value", "updated_at").Upsert(setting).Error(); err != nil {
+		return err
+	}
+
+	if kv.cache != nil {
+		kv.cache.Set(kvCachePrefix+key, encoded, kv.cacheTTL)
+		kv.cache.Delete(kvMissCachePrefix + key)
+	}
+	return nil
+}
+
+func (kv *KVRepository) DeleteValue(key string) error {
+	err := kv.db.Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: key}).Delete(&Setting{}).Error
+	if kv.cache != nil {
+		kv.cache.Delete(kvCachePrefix + key)
+	}
+	return err
+}
+
+func (kv *KVRepository) raw(key string) (string, error) {
+	if kv.cache != nil {
+		if cached, ok := kv.cache.Get(kvCachePrefix + key); ok {
+			return string(cached), nil
+		}
+		if _, missing := kv.cache.Get(kvMissCachePrefix + key); missing && kv.missTTL > 0 {
+			return "", ErrNotFound
+		}
+	}
+
+	if kv.cache == nil {
+		value, err := kv.load(key)
+		return string(value), err
+	}
+	value, err := kv.flights.do(key, func() ([]byte, error) {
+		value, err := kv.load(key)
+		if errors.Is(err, ErrNotFound) && kv.missTTL > 0 {
+			kv.cache.Set(kvMissCachePrefix+key, nil, kv.missTTL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		kv.cache.Set(kvCachePrefix+key, value, kv.cacheTTL)
+		return value, nil
+	})
+	return string(value), err
+}
+
+func (kv *KVRepository) load(key string) ([]byte, error) {
+	var setting Setting
+	if err := kv.db.Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: key}).First(&setting).Error; err != nil {
+		return nil, err
+	}
+	return []byte(setting.Value), nil
+}
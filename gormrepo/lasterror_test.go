@@ -0,0 +1,90 @@
+package gormrepo
+
+import (
+	"errors"
+	"testing"
+)
+
+type testItem struct {
+	ID    int64
+	Name  string
+	Price int64
+}
+
+func TestFailedBuilderStopsFinalizers(t *testing.T) {
+	builders := map[string]func(r *GenericRepository[testItem]) *GenericRepository[testItem]{
+		"WhereTupleIn": func(r *GenericRepository[testItem]) *GenericRepository[testItem] {
+			return r.WhereTupleIn([]string{"id", "name"}, [][]any{{1}})
+		},
+		"SelectFields": func(r *GenericRepository[testItem]) *GenericRepository[testItem] {
+			return r.SelectFields("Missing")
+		},
+		"Filter": func(r *GenericRepository[testItem]) *GenericRepository[testItem] {
+			return r.Filter("Missing", "eq", 1)
+		},
+		"SortBy": func(r *GenericRepository[testItem]) *GenericRepository[testItem] {
+			return r.SortBy("Missing", false)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			finalizers := map[string]func(r *GenericRepository[testItem]) error{
+				"Get": func(r *GenericRepository[testItem]) error {
+					_, err := r.Get()
+					return err
+				},
+				"First": func(r *GenericRepository[testItem]) error {
+					_, err := r.First()
+					return err
+				},
+				"One": func(r *GenericRepository[testItem]) error {
+					_, err := r.One()
+					return err
+				},
+				"Count": func(r *GenericRepository[testItem]) error {
+					_, err := r.Count(nil)
+					return err
+				},
+				"Connection": func(r *GenericRepository[testItem]) error {
+					_, err := r.Connection(10, "", 0, "")
+					return err
+				},
+				"Sample": func(r *GenericRepository[testItem]) error {
+					_, err := r.Sample(3)
+					return err
+				},
+				"LatestPerGroup": func(r *GenericRepository[testItem]) error {
+					_, err := r.LatestPerGroup("Name", "ID")
+					return err
+				},
+				"SnapshotPaginate": func(r *GenericRepository[testItem]) error {
+					_, err := r.SnapshotPaginate(PageTokenRequest{PageSize: 10})
+					return err
+				},
+				"DeleteWhere": func(r *GenericRepository[testItem]) error {
+					return r.DeleteWhere().Error()
+				},
+				"UpdateWhere": func(r *GenericRepository[testItem]) error {
+					return r.UpdateWhere(map[string]interface{}{"price": 1}).Error()
+				},
+			}
+
+			for finalizer, run := range finalizers {
+				db, fake := newTestDB(t, "postgres", "16.2")
+				repo := build(New[testItem](db))
+				want := repo.Error()
+				if want == nil {
+					t.Fatal("builder didn't fail")
+				}
+
+				if err := run(repo); !errors.Is(err, want) {
+					t.Errorf("%s returned %v, want %v", finalizer, err, want)
+				}
+				if stmts := fake.ran(); len(stmts) > 0 {
+					t.Errorf("%s ran %q", finalizer, stmts)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package gormrepo
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LatestPerGroup returns, for each value of groupField, the matching entity
+// with the largest orderField, e.g. the latest status of every device. Ties
+// go to the lowest primary key. Postgres uses DISTINCT ON, other dialects
+// and column policies hiding columns ROW_NUMBER().
+func (r *GenericRepository[T]) LatestPerGroup(groupField, orderField string) (*[]T, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	columns, err := r.columnsOf([]string{groupField, orderField})
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, gorm.ErrPrimaryKeyRequired
+	}
+	group := clause.Column{Table: s.Table, Name: columns[0]}
+	order := clause.Column{Table: s.Table, Name: columns[1]}
+	pk := clause.Column{Table: s.Table, Name: s.PrioritizedPrimaryField.DBName}
+
+	// The columns of DISTINCT ON's ?.* can't be redacted, the outer query of
+	// ROW_NUMBER() can
+	redacting := r.columnPolicy != nil && len(r.columnPolicy.hiddenColumns(r.context(), r.db, new(T))) > 0
+
+	var query *gorm.DB
+	if r.db.Dialector.Name() == "postgres" && !redacting {
+		query = r.db.Model(new(T)).
+			Select("DISTINCT ON (?) ?.*", group, clause.Table{Name: s.Table}).
+			Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+				{Column: group},
+				{Column: order, Desc: true},
+				{Column: pk},
+			}})
+	} else {
+		ranked := r.db.Model(new(T)).Select(
+			"?.*, ROW_NUMBER() OVER (PARTITION BY ? ORDER BY ? DESC, ?) AS row_num__",
+			clause.Table{Name: s.Table}, group, order, pk,
+		)
+		query = r.db.Session(&gorm.Session{NewDB: true}).
+			Table("(?) AS latest", ranked).
+			Where("row_num__ = 1").
+			Order(clause.OrderByColumn{Column: clause.Column{Name: columns[0]}})
+	}
+
+	var entities []T
+	err = r.run(OpQuery, nil, func() error {
+		return r.redacted(r.readDB(query)).Find(&entities).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.afterLoad(pointersTo(entities)...)
+	return &entities, nil
+}
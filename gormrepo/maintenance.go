@@ -0,0 +1,199 @@
+package gormrepo
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaintenanceJob is a periodic database chore, such as archiving old rows,
+// removing duplicates or reconciling counters.
+type MaintenanceJob func(ctx context.Context, db *gorm.DB) error
+
+// RepositoryJob adapts a job written against a repository of T.
+func RepositoryJob[T any](fn func(ctx context.Context, repo *GenericRepository[T]) error) MaintenanceJob {
+	return func(ctx context.Context, db *gorm.DB) error {
+		return fn(ctx, New[T](db.WithContext(ctx)))
+	}
+}
+
+// MaintenanceRun records the last occurrence of a job an instance claimed.
+// Maintenance needs its table migrated.
+type MaintenanceRun struct {
+	Job         string    `gorm:"primaryKey;size:191"`
+	ScheduledAt time.Time // Occurrence of the schedule, or the time of a RunJob call
+	StartedAt   time.Time
+}
+
+func (MaintenanceRun) TableName() string {
+	return "maintenance_runs"
+}
+
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	job      MaintenanceJob
+	next     time.Time
+}
+
+// Maintenance runs registered jobs on their schedules. Every instance of an
+// application can run one: the first instance to claim an occurrence of a
+// job in the maintenance_runs table runs it, the others skip it. On postgres
+// and mysql an advisory lock also keeps runs of a job from overlapping.
+type Maintenance struct {
+	db *gorm.DB
+
+	mu   sync.Mutex
+	jobs []*scheduledJob
+}
+
+func NewMaintenance(db *gorm.DB) *Maintenance {
+	return &Maintenance{db: db}
+}
+
+// Register adds job under a name unique to the application, run on
+// schedule as parsed by ParseSchedule.
+func (m *Maintenance) Register(name, schedule string, job MaintenanceJob) error {
+	s, err := ParseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	next := s.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("schedule %q never runs", schedule)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		if j.name == name {
+			return fmt.Errorf("maintenance job %q already registered", name)
+		}
+	}
+	m.jobs = append(m.jobs, &scheduledJob{name: name, schedule: s, job: job, next: next})
+	return nil
+}
+
+// Run runs the jobs as they come due until ctx is cancelled. Job errors are
+// logged and don't stop the runner.
+func (m *Maintenance) Run(ctx context.Context) error {
+	for {
+		due, wait := m.due(time.Now())
+		for _, occurrence := range due {
+			job := occurrence.job
+			if _, err := m.run(ctx, job, occurrence.at); err != nil && ctx.Err() == nil {
+				m.db.Logger.Error(ctx, "maintenance job %s failed: %v", job.name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RunJob runs the named job now and reports whether it ran, false when
+// another instance holds its lock or has run it since.
+func (m *Maintenance) RunJob(ctx context.Context, name string) (bool, error) {
+	m.mu.Lock()
+	var job *scheduledJob
+	for _, j := range m.jobs {
+		if j.name == name {
+			job = j
+		}
+	}
+	m.mu.Unlock()
+
+	if job == nil {
+		return false, fmt.Errorf("unknown maintenance job %q", name)
+	}
+	return m.run(ctx, job, time.Now())
+}
+
+type occurrence struct {
+	job *scheduledJob
+	at  time.Time
+}
+
+// due returns the jobs to run at now, scheduling their next run, and how
+// long to wait for the next one.
+func (m *Maintenance) due(now time.Time) ([]occurrence, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []occurrence
+	wait := time.Minute
+	for _, job := range m.jobs {
+		if !job.next.After(now) {
+			due = append(due, occurrence{job: job, at: job.next})
+			job.next = job.schedule.Next(now)
+		}
+		if d := job.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return due, wait
+}
+
+// run runs the occurrence of job at the given time, once claimed, while
+// holding its advisory lock.
+func (m *Maintenance) run(ctx context.Context, job *scheduledJob, at time.Time) (bool, error) {
+	db := m.db.WithContext(ctx)
+
+	var lock, unlock string
+	var key any
+	switch db.Dialector.Name() {
+	case "postgres":
+		h := fnv.New64a()
+		h.Write([]byte("gormrepo:" + job.name))
+		lock, unlock, key = "SELECT pg_try_advisory_lock(?)", "SELECT pg_advisory_unlock(?)", int64(h.Sum64())
+	case "mysql":
+		lock, unlock, key = "SELECT GET_LOCK(?, 0) = 1", "SELECT RELEASE_LOCK(?)", "gormrepo:"+job.name
+	default:
+		if claimed, err := claimRun(db, job.name, at); err != nil || !claimed {
+			return false, err
+		}
+		return true, job.job(ctx, db)
+	}
+
+	// Advisory locks belong to a session, so they are taken and released
+	// on one pinned connection
+	ran := false
+	err := db.Connection(func(conn *gorm.DB) error {
+		var locked bool
+		if err := conn.Raw(lock, key).Scan(&locked).Error; err != nil || !locked {
+			return err
+		}
+		defer conn.WithContext(context.WithoutCancel(ctx)).Exec(unlock, key)
+
+		claimed, err := claimRun(db, job.name, at)
+		if err != nil || !claimed {
+			return err
+		}
+		ran = true
+		return job.job(ctx, db)
+	})
+	return ran, err
+}
+
+// claimRun records at as the last occurrence of job, failing to claim it
+// when an instance already recorded it or a later one.
+func claimRun(db *gorm.DB, job string, at time.Time) (bool, error) {
+	db = db.Session(&gorm.Session{NewDB: true})
+	at = at.UTC().Truncate(time.Millisecond) // As stored by dialects keeping milliseconds
+	res := db.Model(&MaintenanceRun{}).Where("job = ? AND scheduled_at < ?", job, at).
+		Updates(map[string]any{"scheduled_at": at, "started_at": time.Now().UTC()})
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.RowsAffected > 0, res.Error
+	}
+	res = db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&MaintenanceRun{Job: job, ScheduledAt: at, StartedAt: time.Now().UTC()})
+	return res.RowsAffected > 0, res.Error
+}
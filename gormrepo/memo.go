@@ -0,0 +1,140 @@
+package gormrepo
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type memoKey struct{}
+
+type requestMemo struct {
+	mu      sync.Mutex
+	results map[string]any
+}
+
+// WithRequestMemo returns a context under which identical First, One and Get
+// calls, same SQL and arguments, run once: later calls get a deep copy of
+// the first result. Writes made with the context clear the memo. Use one per
+// request, as results are kept until the context is dropped.
+func WithRequestMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, memoKey{}, &requestMemo{results: make(map[string]any)})
+}
+
+func memoFromContext(ctx context.Context) *requestMemo {
+	memo, _ := ctx.Value(memoKey{}).(*requestMemo)
+	return memo
+}
+
+func (m *requestMemo) reset() {
+	m.mu.Lock()
+	clear(m.results)
+	m.mu.Unlock()
+}
+
+// memoized returns the result load had for the statement finalize builds on
+// db, calling it only on the first occurrence within the request memo.
+// Failed loads are not remembered.
+func memoized[T, V any](r *GenericRepository[T], db *gorm.DB, finalize func(tx *gorm.DB) *gorm.DB, load func() (V, error)) (V, error) {
+	memo := memoFromContext(r.context())
+	if memo == nil {
+		return load()
+	}
+
+	// Preloads run as separate queries, so they are not part of the SQL
+	key := reflect.TypeFor[V]().String() + ":" + db.ToSQL(finalize)
+	for _, preload := range slices.Sorted(maps.Keys(db.Statement.Preloads)) {
+		key += " preload:" + preload
+	}
+
+	memo.mu.Lock()
+	cached, ok := memo.results[key]
+	memo.mu.Unlock()
+	if ok {
+		return deepCopy(cached.(V)), nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	memo.mu.Lock()
+	memo.results[key] = deepCopy(value)
+	memo.mu.Unlock()
+	return value, nil
+}
+
+// deepCopy returns a copy of value sharing no pointer, slice or map reachable
+// through exported fields with it, so callers can't change memoized results.
+func deepCopy[V any](value V) V {
+	copied := copyValue(reflect.ValueOf(&value).Elem(), map[copiedPointer]reflect.Value{})
+	return copied.Interface().(V)
+}
+
+// copiedPointer identifies a pointer already copied, keeping the sharing and
+// cycles of associations.
+type copiedPointer struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+func copyValue(v reflect.Value, copied map[copiedPointer]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		key := copiedPointer{v.Type(), v.Pointer()}
+		if c, ok := copied[key]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copied[key] = c
+		c.Elem().Set(copyValue(v.Elem(), copied))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(copyValue(v.Index(i), copied))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value(), copied))
+		}
+		return c
+	case reflect.Array, reflect.Struct, reflect.Interface:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		switch v.Kind() {
+		case reflect.Array:
+			for i := range v.Len() {
+				c.Index(i).Set(copyValue(v.Index(i), copied))
+			}
+		case reflect.Struct:
+			// Unexported fields, such as those of time.Time, are copied as they are
+			for i := range v.NumField() {
+				if c.Field(i).CanSet() {
+					c.Field(i).Set(copyValue(v.Field(i), copied))
+				}
+			}
+		case reflect.Interface:
+			if !v.IsNil() {
+				c.Set(copyValue(v.Elem(), copied))
+			}
+		}
+		return c
+	}
+	return v
+}
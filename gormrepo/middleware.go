@@ -0,0 +1,49 @@
+package gormrepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Operation describes a repository operation as seen by middleware.
+type Operation struct {
+	Kind OperationKind
+	// Entity is the entity or slice of entities being written, nil for
+	// queries and writes driven by conditions only.
+	Entity any
+	// Stmt is the statement the operation runs on: the chain for queries,
+	// the write's own statement, inside its transaction if any, for writes.
+	// Conditions added to it apply to the operation.
+	Stmt *gorm.Statement
+	Ctx  context.Context
+
+	logged *[]loggedOperations // Operation records waiting for the commit
+}
+
+type Handler func(op Operation) error
+
+// Middleware wraps the handler of every repository operation. It may inspect
+// or reject the operation, or act around it.
+type Middleware func(next Handler) Handler
+
+// Use adds middleware around every operation of the repository. The first
+// middleware added is the outermost.
+func (r *GenericRepository[T]) Use(mw ...Middleware) *GenericRepository[T] {
+	r.middleware = append(r.middleware, mw...)
+	return r
+}
+
+func (r *GenericRepository[T]) handle(db *gorm.DB, kind OperationKind, target any, work *pendingWork[T], fn func() error) error {
+	if len(r.middleware) == 0 {
+		return fn()
+	}
+
+	handler := func(Operation) error {
+		return fn()
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler(Operation{Kind: kind, Entity: target, Stmt: db.Statement, Ctx: r.context(), logged: &work.operations})
+}
@@ -0,0 +1,89 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+type OperationKind string
+
+const (
+	OpCreate    OperationKind = "create"
+	OpUpdate    OperationKind = "update"
+	OpUpsert    OperationKind = "upsert"
+	OpDelete    OperationKind = "delete"
+	OpAnonymize OperationKind = "anonymize"
+	OpQuery     OperationKind = "query"
+	OpCount     OperationKind = "count"
+)
+
+func (k OperationKind) IsWrite() bool {
+	switch k {
+	case OpCreate, OpUpdate, OpUpsert, OpDelete, OpAnonymize:
+		return true
+	}
+	return false
+}
+
+// prepare completes and validates entities before they are written.
+func (r *GenericRepository[T]) prepare(kind OperationKind, target any) error {
+	if kind == OpCreate || kind == OpUpsert {
+		if err := r.assignIDs(target); err != nil {
+			return err
+		}
+		if err := r.allocateIDs(target); err != nil {
+			return err
+		}
+		if err := r.attach(target); err != nil {
+			return err
+		}
+	}
+	if err := r.uploadFiles(target); err != nil {
+		return err
+	}
+	if r.location != nil {
+		forEachEntity(target, func(entity reflect.Value) error {
+			convertTimes(entity, time.UTC)
+			return nil
+		})
+	}
+	return validateEnums(target)
+}
+
+// afterLoad completes entities read from the database.
+func (r *GenericRepository[T]) afterLoad(entities ...*T) {
+	if err := r.complete(entities...); err != nil {
+		r.lastError = err
+	}
+}
+
+// complete is afterLoad for callers handling the error themselves.
+func (r *GenericRepository[T]) complete(entities ...*T) error {
+	r.pruneCycles(entities...)
+	if r.location != nil {
+		for _, entity := range entities {
+			convertTimes(reflect.ValueOf(entity).Elem(), r.location)
+		}
+	}
+	var errs []error
+	if err := r.resolveBlobs(entities...); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.resolveFileURLs(entities...); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.translate(entities...); err != nil {
+		errs = append(errs, err)
+	}
+	computeFields(entities...)
+	return errors.Join(errs...)
+}
+
+func (r *GenericRepository[T]) context() context.Context {
+	if ctx := r.db.Statement.Context; ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,93 @@
+package gormrepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// operationContextKey is the gorm setting holding the operation context.
+const operationContextKey = "gormrepo:operation"
+
+// operationKey is the context key of the operation context; an unexported
+// type can't collide with keys of other packages.
+type operationKey struct{}
+
+// OperationContext describes the repository operation a statement runs
+// for, so model hooks and gorm plugins can read repository metadata.
+type OperationContext struct {
+	Kind      OperationKind
+	Actor     string
+	Tenant    any
+	RequestID string
+}
+
+type tenantKey struct{}
+
+type requestIDKey struct{}
+
+// ContextWithTenant returns a context carrying the tenant operations run
+// for.
+func ContextWithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func TenantFromContext(ctx context.Context) (any, bool) {
+	tenant := ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// ContextWithRequestID returns a context carrying the id of the request
+// operations run for.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// OperationContextFrom returns the operation context of a statement, e.g.
+// from a BeforeCreate(tx *gorm.DB) hook or a gorm callback. Statements gorm
+// starts on its own, such as association saves and preloads, carry it in
+// their context instead of their settings.
+func OperationContextFrom(db *gorm.DB) (OperationContext, bool) {
+	if oc, ok := db.Get(operationContextKey); ok {
+		return oc.(OperationContext), true
+	}
+	if db.Statement.Context == nil {
+		return OperationContext{}, false
+	}
+	oc, ok := db.Statement.Context.Value(operationKey{}).(OperationContext)
+	return oc, ok
+}
+
+// operationCtx carries the operation context. Repositories attach one for
+// each operation, so it replaces rather than wraps an earlier one.
+type operationCtx struct {
+	context.Context
+	operation OperationContext
+}
+
+func (c *operationCtx) Value(key any) any {
+	if key == (operationKey{}) {
+		return c.operation
+	}
+	return c.Context.Value(key)
+}
+
+// withOperationContext attaches the context of an operation of kind to db.
+func (r *GenericRepository[T]) withOperationContext(db *gorm.DB, kind OperationKind) *gorm.DB {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c, ok := ctx.(*operationCtx); ok {
+		ctx = c.Context
+	}
+
+	oc := OperationContext{Kind: kind, Actor: ActorFromContext(ctx), RequestID: RequestIDFromContext(ctx)}
+	oc.Tenant, _ = TenantFromContext(ctx)
+	return db.WithContext(&operationCtx{Context: ctx, operation: oc}).Set(operationContextKey, oc)
+}
@@ -0,0 +1,326 @@
+package gormrepo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type actorKey struct{}
+
+// ContextWithActor returns a context carrying who performs the operations,
+// as recorded in the operation log.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// OperationRecord is one entry of the operation log: a write of one entity,
+// or of the rows matching Condition for writes such as UpdateWhere.
+type OperationRecord struct {
+	At        time.Time      `json:"at"`
+	Actor     string         `json:"actor,omitempty"`
+	Kind      OperationKind  `json:"kind"`
+	Table     string         `json:"table"`
+	Keys      map[string]any `json:"keys,omitempty"`
+	Condition string         `json:"condition,omitempty"`
+	Columns   []string       `json:"columns,omitempty"`
+	Changes   []ColumnChange `json:"changes,omitempty"` // Only with values enabled
+}
+
+type ColumnChange struct {
+	Column string `json:"column"`
+	Old    any    `json:"old"`
+	New    any    `json:"new"`
+}
+
+// OperationSink stores operation records. Sinks must only ever append.
+type OperationSink interface {
+	WriteOperations(ctx context.Context, records []OperationRecord) error
+}
+
+type OperationSinkFunc func(ctx context.Context, records []OperationRecord) error
+
+func (f OperationSinkFunc) WriteOperations(ctx context.Context, records []OperationRecord) error {
+	return f(ctx, records)
+}
+
+// OperationLogEntry is a row of the table written by NewTableOperationSink.
+type OperationLogEntry struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	At        time.Time `gorm:"index"`
+	Actor     string    `gorm:"size:255;index"`
+	Kind      string    `gorm:"size:32"`
+	Table     string    `gorm:"column:table_name;size:255;index"`
+	Keys      []byte
+	Condition string `gorm:"type:text"`
+	Columns   []byte
+	Changes   []byte
+}
+
+func (OperationLogEntry) TableName() string {
+	return "operation_log"
+}
+
+// NewTableOperationSink inserts records into the operation_log table of db.
+func NewTableOperationSink(db *gorm.DB) OperationSink {
+	return OperationSinkFunc(func(ctx context.Context, records []OperationRecord) error {
+		entries := make([]OperationLogEntry, len(records))
+		for i, rec := range records {
+			entries[i] = OperationLogEntry{At: rec.At, Actor: rec.Actor, Kind: string(rec.Kind), Table: rec.Table, Condition: rec.Condition}
+			entries[i].Keys, _ = json.Marshal(rec.Keys)
+			entries[i].Columns, _ = json.Marshal(rec.Columns)
+			entries[i].Changes, _ = json.Marshal(rec.Changes)
+		}
+		return db.WithContext(ctx).Create(&entries).Error
+	})
+}
+
+// NewWriterOperationSink writes records to w as JSON lines, e.g. to a file
+// opened for appending.
+func NewWriterOperationSink(w io.Writer) OperationSink {
+	var mu sync.Mutex
+	return OperationSinkFunc(func(ctx context.Context, records []OperationRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		enc := json.NewEncoder(w)
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// NewWebhookOperationSink posts records to url as a JSON array.
+func NewWebhookOperationSink(url string, client *http.Client) OperationSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return OperationSinkFunc(func(ctx context.Context, records []OperationRecord) error {
+		body, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("operation log webhook returned %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// WithOperationLog records every write of the repository to sink once it
+// committed, after the surrounding transaction if any; writes rolled back
+// are not recorded. With values, the row is read before updates and deletes
+// so records carry the old and new value of each changed column, except for
+// fields tagged pii, which are listed without values so the log holds no
+// personal data Anonymize couldn't erase. Writes driven by conditions, such
+// as UpdateWhere, are recorded with their condition; with values, its
+// arguments are filled in and one record is made per matching row. Writes
+// of other tables, such as NextCounter, are recorded with their table only.
+// A sink failure is reported like a failing AfterCommit hook.
+func (r *GenericRepository[T]) WithOperationLog(sink OperationSink, values bool) *GenericRepository[T] {
+	return r.Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			if !op.Kind.IsWrite() {
+				return next(op)
+			}
+
+			s, err := parseSchema(r.db, new(T))
+			if err != nil {
+				return err
+			}
+
+			if table := op.Stmt.Table; table != "" && table != s.Table {
+				if err := next(op); err != nil {
+					return err
+				}
+				record := OperationRecord{At: time.Now(), Actor: ActorFromContext(op.Ctx), Kind: op.Kind, Table: table}
+				return logOperations(op, sink, []OperationRecord{record})
+			}
+
+			var before map[string]map[string]any
+			var matched []map[string]any
+			if values && (op.Kind == OpUpdate || op.Kind == OpUpsert || op.Kind == OpDelete || op.Kind == OpAnonymize) {
+				if op.Entity == nil {
+					matched = matchedKeys(op, s)
+				} else {
+					before = rowsBefore(op, s)
+				}
+			}
+
+			if err := next(op); err != nil {
+				return err
+			}
+			return logOperations(op, sink, operationRecords(op, s, values, before, matched))
+		}
+	})
+}
+
+// loggedOperations are operation records waiting for their write to commit.
+type loggedOperations struct {
+	ctx     context.Context
+	sink    OperationSink
+	records []OperationRecord
+}
+
+// logOperations defers writing the records of op to sink until op commits.
+func logOperations(op Operation, sink OperationSink, records []OperationRecord) error {
+	if op.logged == nil {
+		return sink.WriteOperations(op.Ctx, records)
+	}
+	*op.logged = append(*op.logged, loggedOperations{ctx: op.Ctx, sink: sink, records: records})
+	return nil
+}
+
+func writeOperations(operations []loggedOperations) error {
+	var errs []error
+	for _, logged := range operations {
+		if err := logged.sink.WriteOperations(logged.ctx, logged.records); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rowsBefore reads the stored columns of the entities op writes, keyed by
+// their encoded primary key.
+func rowsBefore(op Operation, s *schema.Schema) map[string]map[string]any {
+	rows := map[string]map[string]any{}
+	forEachEntity(op.Entity, func(entity reflect.Value) error {
+		keys := entityKeys(op.Ctx, s, entity)
+		if keys == nil {
+			return nil
+		}
+		row := map[string]any{}
+		if err := op.Stmt.DB.Session(&gorm.Session{NewDB: true, Context: op.Ctx}).Table(s.Table).Where(keys).Take(&row).Error; err == nil {
+			rows[keyString(keys)] = row
+		}
+		return nil
+	})
+	return rows
+}
+
+// matchedKeys reads the primary keys of the rows matching the conditions of
+// op, a write without entities.
+func matchedKeys(op Operation, s *schema.Schema) []map[string]any {
+	var columns []string
+	for _, pk := range s.PrimaryFields {
+		columns = append(columns, pk.DBName)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	tx := op.Stmt.DB.Session(&gorm.Session{Context: op.Ctx}).Table(s.Table).Select(columns)
+	tx.Statement.Preloads = nil
+	var keys []map[string]any
+	if err := tx.Find(&keys).Error; err != nil {
+		return nil
+	}
+	return keys
+}
+
+// condition returns the WHERE conditions of stmt as SQL, with the arguments
+// filled in when values is set.
+func condition(stmt *gorm.Statement, s *schema.Schema, values bool) string {
+	where, ok := stmt.Clauses["WHERE"]
+	if !ok || where.Expression == nil {
+		return ""
+	}
+	built := &gorm.Statement{DB: stmt.DB, Schema: s, Table: s.Table, Clauses: map[string]clause.Clause{}}
+	where.Expression.Build(built)
+	if !values {
+		return built.SQL.String()
+	}
+	return stmt.DB.Dialector.Explain(built.SQL.String(), built.Vars...)
+}
+
+func operationRecords(op Operation, s *schema.Schema, values bool, before map[string]map[string]any, matched []map[string]any) []OperationRecord {
+	base := OperationRecord{At: time.Now(), Actor: ActorFromContext(op.Ctx), Kind: op.Kind, Table: s.Table}
+	if op.Entity == nil {
+		base.Condition = condition(op.Stmt, s, values)
+		if len(matched) == 0 {
+			return []OperationRecord{base}
+		}
+		records := make([]OperationRecord, len(matched))
+		for i, keys := range matched {
+			records[i] = base
+			records[i].Keys = keys
+		}
+		return records
+	}
+
+	var records []OperationRecord
+	forEachEntity(op.Entity, func(entity reflect.Value) error {
+		rec := base
+		rec.Keys = entityKeys(op.Ctx, s, entity)
+		if op.Kind == OpDelete {
+			records = append(records, rec)
+			return nil
+		}
+
+		old := before[keyString(rec.Keys)]
+		for _, field := range s.Fields {
+			if field.DBName == "" || field.PrimaryKey {
+				continue
+			}
+			value, _ := field.ValueOf(op.Ctx, entity)
+			if values && old != nil && fmt.Sprint(old[field.DBName]) == fmt.Sprint(value) {
+				continue
+			}
+			rec.Columns = append(rec.Columns, field.DBName)
+			if values && field.Tag.Get("pii") == "" {
+				rec.Changes = append(rec.Changes, ColumnChange{Column: field.DBName, Old: old[field.DBName], New: value})
+			}
+		}
+		records = append(records, rec)
+		return nil
+	})
+	return records
+}
+
+// entityKeys returns the primary key columns and values of entity, or nil
+// when they are not set.
+func entityKeys(ctx context.Context, s *schema.Schema, entity reflect.Value) map[string]any {
+	keys := map[string]any{}
+	for _, pk := range s.PrimaryFields {
+		value, isZero := pk.ValueOf(ctx, entity)
+		if isZero {
+			return nil
+		}
+		keys[pk.DBName] = value
+	}
+	return keys
+}
+
+func keyString(keys map[string]any) string {
+	b, _ := json.Marshal(keys)
+	return string(b)
+}
@@ -0,0 +1,77 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// recordingSink keeps the records written to it, with the last statement
+// run when they were.
+type recordingSink struct {
+	fake    *fakeDB
+	records []OperationRecord
+	after   []string
+}
+
+func (s *recordingSink) WriteOperations(_ context.Context, records []OperationRecord) error {
+	s.records = append(s.records, records...)
+	ran := s.fake.ran()
+	s.after = append(s.after, ran[len(ran)-1])
+	return nil
+}
+
+func TestOperationLogWritesOnCommit(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	sink := &recordingSink{fake: fake}
+	r := New[testItem](db.WithContext(ContextWithActor(context.Background(), "ann"))).WithOperationLog(sink, false)
+
+	err := r.Transaction(func(tx *GenericRepository[testItem]) error {
+		if err := tx.Create(&testItem{ID: 7, Name: "lamp"}).Error(); err != nil {
+			return err
+		}
+		if len(sink.records) != 0 {
+			t.Errorf("wrote %+v before the commit", sink.records)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 1 || sink.after[0] != "COMMIT" {
+		t.Fatalf("wrote %+v after %q, want one record after COMMIT", sink.records, sink.after)
+	}
+	rec := sink.records[0]
+	if rec.Kind != OpCreate || rec.Table != "test_items" || rec.Actor != "ann" || rec.Keys["id"] != int64(7) {
+		t.Errorf("wrote %+v", rec)
+	}
+}
+
+func TestOperationLogDropsRolledBackWrites(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	sink := &recordingSink{fake: fake}
+	r := New[testItem](db).WithOperationLog(sink, false)
+
+	failed := errors.New("failed")
+	err := r.Transaction(func(tx *GenericRepository[testItem]) error {
+		if err := tx.Create(&testItem{ID: 7, Name: "lamp"}).Error(); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("got %v", err)
+	}
+	if len(sink.records) != 0 {
+		t.Errorf("wrote %+v for a rolled back write", sink.records)
+	}
+
+	// Outside a transaction, once the write's own one commits
+	if err := r.Delete(7).Error(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.records) != 1 || sink.records[0].Kind != OpDelete || sink.after[0] != "COMMIT" {
+		t.Errorf("wrote %+v after %q", sink.records, sink.after)
+	}
+}
@@ -0,0 +1,86 @@
+package gormrepo
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const unorderedKey = "gormrepo:unordered"
+
+// WithDefaultOrder orders list queries that don't set their own order, e.g.
+// WithDefaultOrder("created_at DESC"), so lists are deterministic.
+func (r *GenericRepository[T]) WithDefaultOrder(order string) *GenericRepository[T] {
+	r.defaultOrder = order
+	return r
+}
+
+// Unordered leaves the chain without the default order, for queries whose
+// order doesn't matter.
+func (r *GenericRepository[T]) Unordered() *GenericRepository[T] {
+	r.db = r.db.Set(unorderedKey, true)
+	return r
+}
+
+// orderedQuery applies the default order to db when it has no order.
+func (r *GenericRepository[T]) orderedQuery(db *gorm.DB) *gorm.DB {
+	if r.defaultOrder == "" || hasOrder(db) {
+		return db
+	}
+	if unordered, _ := db.Get(unorderedKey); unordered == true {
+		return db
+	}
+	return db.Order(r.defaultOrder)
+}
+
+func hasOrder(db *gorm.DB) bool {
+	_, ok := db.Statement.Clauses["ORDER BY"]
+	return ok
+}
+
+// WithoutTieBreaker stops paginated queries from being ordered by the
+// primary key after their own order.
+func (r *GenericRepository[T]) WithoutTieBreaker() *GenericRepository[T] {
+	r.noTieBreaker = true
+	return r
+}
+
+// tieBroken orders db by the primary key after its own order, so that rows
+// sharing the sort values can't repeat or go missing across pages.
+func (r *GenericRepository[T]) tieBroken(db *gorm.DB) *gorm.DB {
+	// Grouped and distinct rows can't be ordered by a column they don't select
+	if _, grouped := db.Statement.Clauses["GROUP BY"]; r.noTieBreaker || grouped || db.Statement.Distinct {
+		return db
+	}
+	s, err := parseSchema(db, new(T))
+	if err != nil || s.PrioritizedPrimaryField == nil {
+		return db
+	}
+	pk := s.PrioritizedPrimaryField.DBName
+
+	if c, ok := db.Statement.Clauses["ORDER BY"]; ok {
+		orderBy, ok := c.Expression.(clause.OrderBy)
+		if !ok || orderBy.Expression != nil {
+			return db
+		}
+		for _, column := range orderBy.Columns {
+			name := column.Column.Name
+			if column.Column.Raw {
+				name, _, _ = strings.Cut(strings.TrimSpace(name), " ")
+				name = strings.ReplaceAll(strings.ReplaceAll(name, `"`, ""), "`", "")
+				name = name[strings.LastIndex(name, ".")+1:]
+			}
+			if name == pk {
+				return db
+			}
+		}
+	}
+	return db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: pk}})
+}
+
+// paged reports whether db reads a page of rows.
+func paged(db *gorm.DB) bool {
+	_, hasLimit := currentLimit(db)
+	return hasLimit || currentOffset(db) > 0
+}
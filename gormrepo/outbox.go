@@ -0,0 +1,181 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Publisher delivers outbox messages to a message broker. Delivery is
+// at-least-once: a message may be published again if marking it fails.
+type Publisher interface {
+	Publish(ctx context.Context, msg OutboxMessage) error
+}
+
+type PublisherFunc func(ctx context.Context, msg OutboxMessage) error
+
+func (f PublisherFunc) Publish(ctx context.Context, msg OutboxMessage) error {
+	return f(ctx, msg)
+}
+
+// KafkaProducer is the subset of a Kafka client used by NewKafkaPublisher.
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// NATSPublisher matches (*nats.Conn).Publish.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// SQSSender is the subset of an SQS client used by NewSQSPublisher.
+type SQSSender interface {
+	SendMessage(ctx context.Context, queueURL string, body string, attributes map[string]string) error
+}
+
+// NewKafkaPublisher publishes each message to topic(msg), keyed by event type.
+func NewKafkaPublisher(producer KafkaProducer, topic func(msg OutboxMessage) string) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg OutboxMessage) error {
+		return producer.Produce(ctx, topic(msg), []byte(msg.EventType), msg.Payload)
+	})
+}
+
+func NewNATSPublisher(conn NATSPublisher, subject func(msg OutboxMessage) string) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg OutboxMessage) error {
+		return conn.Publish(subject(msg), msg.Payload)
+	})
+}
+
+func NewSQSPublisher(sender SQSSender, queueURL string) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg OutboxMessage) error {
+		return sender.SendMessage(ctx, queueURL, string(msg.Payload), map[string]string{"event_type": msg.EventType})
+	})
+}
+
+// OutboxWorker publishes pending outbox messages. Several workers can run
+// concurrently: rows are claimed with FOR UPDATE SKIP LOCKED where supported.
+type OutboxWorker struct {
+	db           *gorm.DB
+	publisher    Publisher
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+}
+
+func NewOutboxWorker(db *gorm.DB, publisher Publisher) *OutboxWorker {
+	return &OutboxWorker{db: db, publisher: publisher, batchSize: 100, pollInterval: time.Second}
+}
+
+func (w *OutboxWorker) WithBatchSize(n int) *OutboxWorker {
+	w.batchSize = n
+	return w
+}
+
+func (w *OutboxWorker) WithPollInterval(d time.Duration) *OutboxWorker {
+	w.pollInterval = d
+	return w
+}
+
+// WithMaxAttempts parks a message once publishing it failed n times, so it
+// no longer holds back the messages after it. Parked messages are listed by
+// ParkedMessages and published again after Requeue. Without it, a message
+// is retried until it is published.
+func (w *OutboxWorker) WithMaxAttempts(n int) *OutboxWorker {
+	w.maxAttempts = n
+	return w
+}
+
+// Run processes batches until ctx is cancelled, waiting pollInterval whenever
+// the outbox is empty or publishing failed.
+func (w *OutboxWorker) Run(ctx context.Context) error {
+	for {
+		n, err := w.ProcessBatch(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil && n == w.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// ProcessBatch claims up to batchSize unpublished messages, publishes them in
+// order and marks them published. Publishing stops at the first failure so
+// per-aggregate ordering is kept; the failed message is retried next batch,
+// unless it reached the maximum attempts and is parked.
+func (w *OutboxWorker) ProcessBatch(ctx context.Context) (int, error) {
+	published := 0
+	var publishErr error
+
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := forUpdate(tx.Where("published_at IS NULL AND parked_at IS NULL").Order("id").Limit(w.batchSize), true)
+
+		var messages []OutboxMessage
+		if err := query.Find(&messages).Error; err != nil {
+			return err
+		}
+
+		var ids []uint64
+		for _, msg := range messages {
+			if publishErr = w.publisher.Publish(ctx, msg); publishErr != nil {
+				updates := map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": publishErr.Error(),
+				}
+				if w.maxAttempts > 0 && msg.Attempts+1 >= w.maxAttempts {
+					updates["parked_at"] = tx.NowFunc()
+				}
+				err := tx.Model(&OutboxMessage{}).Where("id = ?", msg.ID).Updates(updates).Error
+				if err != nil {
+					return errors.Join(publishErr, err)
+				}
+				break
+			}
+			ids = append(ids, msg.ID)
+		}
+
+		if len(ids) > 0 {
+			err := tx.Model(&OutboxMessage{}).Where("id IN ?", ids).Update("published_at", tx.NowFunc()).Error
+			if err != nil {
+				return err
+			}
+		}
+		published = len(ids)
+
+		// What was published is committed even if a later message failed
+		return nil
+	})
+	if err != nil {
+		return published, err
+	}
+
+	return published, publishErr
+}
+
+// ParkedMessages returns the messages parked after failing the maximum
+// attempts, oldest first.
+func (w *OutboxWorker) ParkedMessages(ctx context.Context) ([]OutboxMessage, error) {
+	var messages []OutboxMessage
+	err := w.db.WithContext(ctx).Where("parked_at IS NOT NULL AND published_at IS NULL").Order("id").Find(&messages).Error
+	return messages, err
+}
+
+// Requeue unparks the given messages with their attempts reset, once the
+// cause of their failure is fixed.
+func (w *OutboxWorker) Requeue(ctx context.Context, ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return w.db.WithContext(ctx).Model(&OutboxMessage{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"parked_at": nil,
+		"attempts":  0,
+	}).Error
+}
@@ -0,0 +1,145 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotOwner           = errors.New("row belongs to another user")
+	ErrOwnershipUnchecked = errors.New("upsert can't be limited to the rows of the user")
+)
+
+type userKey struct{}
+
+// ContextWithUser returns a context carrying the id of the user operations
+// run for, used by WithOwnership.
+func ContextWithUser(ctx context.Context, userID any) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+func UserFromContext(ctx context.Context) (any, bool) {
+	userID := ctx.Value(userKey{})
+	return userID, userID != nil
+}
+
+// WithOwnership restricts the repository to the rows of the user in the
+// context: queries, updates and deletes only match rows whose field holds
+// the user id, creates stamp it, and writes of an entity stored under
+// another user fail with ErrNotOwner. The stored owner is read on the
+// connection of the write and locked, so inside a transaction it can't change
+// before the write. Upserts only update rows of the user on Postgres and
+// SQLite; elsewhere upserts with conflict columns fail with
+// ErrOwnershipUnchecked. Contexts without a user are not restricted.
+func (r *GenericRepository[T]) WithOwnership(field string) *GenericRepository[T] {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	owner := s.LookUpField(field)
+	if owner == nil || owner.DBName == "" {
+		r.lastError = fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, field)
+		return r
+	}
+	r.owner = owner
+	r.db = r.owned(r.db)
+
+	return r.Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			userID, ok := UserFromContext(op.Ctx)
+			if !ok || op.Entity == nil {
+				return next(op)
+			}
+
+			err := forEachEntity(op.Entity, func(entity reflect.Value) error {
+				if op.Kind == OpCreate || op.Kind == OpUpsert {
+					if err := owner.Set(op.Ctx, entity, userID); err != nil {
+						return err
+					}
+				}
+				if op.Kind == OpCreate {
+					return nil
+				}
+
+				// gorm's Save inserts when no row matched the update, so a
+				// row of another user must be caught before it is overwritten
+				keys := entityKeys(op.Ctx, s, entity)
+				if keys == nil {
+					return nil
+				}
+				var stored []any
+				err := forUpdate(op.Stmt.DB.Session(&gorm.Session{NewDB: true, Context: op.Ctx}), false).
+					Table(s.Table).Where(keys).Pluck(owner.DBName, &stored).Error
+				if err != nil {
+					return err
+				}
+				if len(stored) > 0 && fmt.Sprint(stored[0]) != fmt.Sprint(userID) {
+					return ErrNotOwner
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return next(op)
+		}
+	})
+}
+
+// owned restricts the queries of db to the rows of the user in the context,
+// for queries built on a new session rather than on the chain.
+func (r *GenericRepository[T]) owned(db *gorm.DB) *gorm.DB {
+	if r.owner == nil {
+		return db
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: r.owner.DBName}
+
+	// Scopes run when the statement executes, with the context it has then
+	return db.Scopes(func(db *gorm.DB) *gorm.DB {
+		if userID, ok := UserFromContext(db.Statement.Context); ok {
+			return db.Where(clause.Eq{Column: column, Value: userID})
+		}
+		return db
+	})
+}
+
+// ownershipApplies reports whether the reads of r are restricted to a user.
+func (r *GenericRepository[T]) ownershipApplies() bool {
+	if r.owner == nil {
+		return false
+	}
+	_, ok := UserFromContext(r.context())
+	return ok
+}
+
+// ownedConflict limits the update of an upsert to the rows of the user in
+// the context, so a conflict on a column other than the primary key can't
+// overwrite the row of another user.
+func (r *GenericRepository[T]) ownedConflict(db *gorm.DB, onConflict clause.OnConflict) (clause.OnConflict, error) {
+	if r.owner == nil || onConflict.DoNothing {
+		return onConflict, nil
+	}
+	if _, ok := UserFromContext(db.Statement.Context); !ok {
+		return onConflict, nil
+	}
+
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite":
+		onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: r.owner.DBName},
+			Value:  clause.Column{Table: "excluded", Name: r.owner.DBName},
+		})
+	default:
+		// ON DUPLICATE KEY UPDATE has no condition
+		if len(onConflict.Columns) > 0 {
+			return onConflict, ErrOwnershipUnchecked
+		}
+	}
+	return onConflict, nil
+}
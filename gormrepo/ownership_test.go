@@ -0,0 +1,97 @@
+package gormrepo
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ownedItem struct {
+	ID      int64
+	OwnerID int64
+	Name    string
+}
+
+var (
+	ownedColumns  = []string{"id", "owner_id", "name"}
+	ownerArgument = regexp.MustCompile(`\[(.* )?5( .*)?\]$`)
+)
+
+// newOwnedRepository returns a repository restricted to the rows of user 5.
+func newOwnedRepository(t *testing.T) (*GenericRepository[ownedItem], *fakeDB) {
+	t.Helper()
+	db, fake := newTestDB(t, "postgres", "16.2")
+	ctx := ContextWithUser(context.Background(), int64(5))
+	return New[ownedItem](db.WithContext(ctx)).WithOwnership("OwnerID"), fake
+}
+
+// checkOwnerCondition checks every query run restricts the owner to user 5.
+func checkOwnerCondition(t *testing.T, fake *fakeDB, want int) {
+	t.Helper()
+	got := fake.ranLike(" FROM ")
+	if len(got) != want {
+		t.Errorf("ran %q, want %d queries", got, want)
+	}
+	for _, stmt := range got {
+		if !strings.Contains(stmt, `"owned_items"."owner_id" = ?`) || !ownerArgument.MatchString(stmt) {
+			t.Errorf("ran %q without the owner condition", stmt)
+		}
+	}
+}
+
+func TestOwnershipRestrictsQueries(t *testing.T) {
+	r, fake := newOwnedRepository(t)
+	if _, err := r.Get(); err != nil {
+		t.Fatal(err)
+	}
+	checkOwnerCondition(t, fake, 1)
+}
+
+func TestOwnershipStampsCreates(t *testing.T) {
+	r, fake := newOwnedRepository(t)
+	item := &ownedItem{OwnerID: 6, Name: "lamp"}
+	if err := r.Create(item).Error(); err != nil {
+		t.Fatal(err)
+	}
+	if item.OwnerID != 5 {
+		t.Errorf("owner is %d, want 5", item.OwnerID)
+	}
+	if got := fake.ranLike("INSERT"); len(got) != 1 || !strings.Contains(got[0], "[5 lamp]") {
+		t.Errorf("ran %q", got)
+	}
+}
+
+func TestOwnershipRejectsUpdatesOfOtherUsers(t *testing.T) {
+	r, fake := newOwnedRepository(t)
+	fake.queue([]string{"owner_id"}, []driver.Value{int64(6)})
+	err := r.Update(&ownedItem{ID: 7, Name: "lamp"}).Error()
+	if !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("got %v, want ErrNotOwner", err)
+	}
+	if got := fake.ranLike("UPDATE \"owned_items\""); len(got) != 0 {
+		t.Errorf("ran %q", got)
+	}
+}
+
+func TestOwnershipRestrictsCachedAndRefreshedReads(t *testing.T) {
+	r, fake := newOwnedRepository(t)
+	r.WithCache(NewMemoryCache(), time.Minute)
+
+	for i := 0; i < 2; i++ {
+		fake.queue(ownedColumns, []driver.Value{int64(7), int64(5), "lamp"})
+		if _, err := r.FindByIDCached(7); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkOwnerCondition(t, fake, 2)
+
+	fake.queue(ownedColumns, []driver.Value{int64(7), int64(5), "lamp"})
+	if err := r.Refresh(&ownedItem{ID: 7}).Error(); err != nil {
+		t.Fatal(err)
+	}
+	checkOwnerCondition(t, fake, 3)
+}
@@ -0,0 +1,101 @@
+package gormrepo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PageTokenRequest mirrors the page_size/page_token pair of an AIP-158 List request.
+type PageTokenRequest struct {
+	PageSize  int
+	PageToken string
+}
+
+type PageTokenResponse[T any] struct {
+	Items         *[]T
+	NextPageToken string // Empty when there are no more results
+}
+
+type pageToken struct {
+	Offset int `json:"o"`
+}
+
+func EncodePageToken(offset int) string {
+	payload, _ := json.Marshal(pageToken{Offset: offset})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func DecodePageToken(token string) (int, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	var decoded pageToken
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	if decoded.Offset < 0 {
+		return 0, fmt.Errorf("%w: negative offset", ErrInvalidPageToken)
+	}
+
+	return decoded.Offset, nil
+}
+
+func (r *GenericRepository[T]) ListPage(req PageTokenRequest) (*PageTokenResponse[T], error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if req.PageSize < 0 {
+		return nil, fmt.Errorf("page size cannot be negative")
+	}
+
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	offset := 0
+	if req.PageToken != "" {
+		decoded, err := DecodePageToken(req.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		offset = decoded
+	}
+	if err := r.checkOffset(offset); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to know whether another page exists
+	var entities []T
+	err := r.run(OpQuery, nil, func() error {
+		return unorderedPage(r.redacted(r.readDB(r.tieBroken(r.orderedQuery(r.db)))).Offset(offset).Limit(pageSize + 1)).Find(&entities).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nextPageToken := ""
+	if len(entities) > pageSize {
+		entities = entities[:pageSize]
+		nextPageToken = EncodePageToken(offset + pageSize)
+	}
+	r.afterLoad(pointersTo(entities)...)
+
+	r.currentSlice = &entities
+	return &PageTokenResponse[T]{Items: &entities, NextPageToken: nextPageToken}, nil
+}
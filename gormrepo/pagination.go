@@ -0,0 +1,108 @@
+package gormrepo
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// RowNumPagination is a gorm plugin paginating with ROWNUM on Oracle
+// releases before 12c, which lack the OFFSET FETCH gorm's Oracle drivers
+// use. It has no effect on other databases:
+//
+//	db.Use(gormrepo.RowNumPagination{})
+type RowNumPagination struct{}
+
+func (RowNumPagination) Name() string {
+	return "gormrepo:rownum_pagination"
+}
+
+func (RowNumPagination) Initialize(db *gorm.DB) error {
+	caps := DetectCapabilities(db)
+	if caps.Dialect != "oracle" || caps.OffsetFetch {
+		return nil
+	}
+	db.ClauseBuilders["LIMIT"] = buildRowNumLimit
+	return nil
+}
+
+// buildRowNumLimit wraps the query built so far in ROWNUM filters. The
+// wrapper adds no variables before the query's own, so their positions hold.
+func buildRowNumLimit(c clause.Clause, builder clause.Builder) {
+	limit, ok := c.Expression.(clause.Limit)
+	stmt, isStmt := builder.(*gorm.Statement)
+	if !ok || !isStmt || (limit.Limit == nil && limit.Offset <= 0) {
+		return
+	}
+
+	query := stmt.SQL.String()
+	stmt.SQL.Reset()
+	stmt.SQL.WriteString("SELECT * FROM (SELECT rownum_page_.*, ROWNUM rownum_ FROM (")
+	stmt.SQL.WriteString(query)
+	stmt.SQL.WriteString(") rownum_page_")
+	if limit.Limit != nil {
+		stmt.SQL.WriteString(" WHERE ROWNUM <= ")
+		stmt.AddVar(stmt, limit.Offset+max(*limit.Limit, 0))
+	}
+	stmt.SQL.WriteString(") WHERE rownum_ > ")
+	stmt.AddVar(stmt, limit.Offset)
+}
+
+// unorderedPage orders a page of db that has no order on SQL Server, whose
+// OFFSET FETCH needs one. The driver would order by the primary key, which
+// grouped and distinct queries can't use.
+func unorderedPage(db *gorm.DB) *gorm.DB {
+	if hasOrder(db) || !paged(db) || db.Dialector.Name() != "sqlserver" {
+		return db
+	}
+	return db.Order("(SELECT NULL)")
+}
+
+// withIdentityInsert lets a create or upsert of target on SQL Server write
+// the primary keys it carries into an identity column.
+func (r *GenericRepository[T]) withIdentityInsert(kind OperationKind, target any, op func(db *gorm.DB) error) func(db *gorm.DB) error {
+	if (kind != OpCreate && kind != OpUpsert) || r.db.Dialector.Name() != "sqlserver" {
+		return op
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil || !identityKey(s) {
+		return op
+	}
+
+	explicit := false
+	forEachEntity(target, func(entity reflect.Value) error {
+		if _, zero := s.PrioritizedPrimaryField.ValueOf(r.context(), entity); !zero {
+			explicit = true
+		}
+		return nil
+	})
+	if !explicit {
+		return op
+	}
+	return func(db *gorm.DB) error {
+		return identityInsert(db, s.Table, op)
+	}
+}
+
+// identityInsert runs fn with IDENTITY_INSERT on for table. The setting
+// belongs to a session, which the transaction pins, and can only be on for
+// one table at a time. It isn't rolled back with the transaction, so it is
+// turned off whether fn fails or not.
+func identityInsert(db *gorm.DB, table string, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) (err error) {
+		if err := tx.Exec("SET IDENTITY_INSERT ? ON", clause.Table{Name: table}).Error; err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, tx.Exec("SET IDENTITY_INSERT ? OFF", clause.Table{Name: table}).Error)
+		}()
+		return fn(tx)
+	})
+}
+
+func identityKey(s *schema.Schema) bool {
+	return s.PrioritizedPrimaryField != nil && s.PrioritizedPrimaryField.AutoIncrement
+}
@@ -0,0 +1,100 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+var ErrFieldNotLoaded = errors.New("field not loaded")
+
+// FieldMask lists the fields of an entity, by Go field name, that were loaded.
+type FieldMask []string
+
+func (m FieldMask) Has(field string) bool {
+	return slices.Contains(m, field)
+}
+
+// Guard fails with ErrFieldNotLoaded when one of fields is not in m. The
+// check only runs in builds with the gormrepo_debug tag.
+func (m FieldMask) Guard(fields ...string) error {
+	if !partialGuards {
+		return nil
+	}
+	for _, field := range fields {
+		if !m.Has(field) {
+			return fmt.Errorf("%w: %s", ErrFieldNotLoaded, field)
+		}
+	}
+	return nil
+}
+
+// ProjectToPartial loads entities with only the columns of the DTO filled,
+// leaving the other fields zero. LoadedFields reports which ones were loaded.
+// Update and Upsert through the repository only write the loaded fields, so
+// partial entities must be written back through it: a repository loading
+// whole entities would overwrite the fields left out with zero.
+func (r *GenericRepository[T]) ProjectToPartial(dtoInterface interface{}) *GenericRepository[T] {
+	newRepo := r.clone(r.db)
+	newRepo.projection = dtoInterface
+	newRepo.projectionMode = "partial"
+	newRepo.lastError = r.lastError
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		newRepo.lastError = err
+		return newRepo
+	}
+
+	var columns []string
+	var mask FieldMask
+	for _, column := range createProjectionFromDTO(dtoInterface, r.tagPriority) {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			newRepo.lastError = fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, column)
+			return newRepo
+		}
+		columns = append(columns, field.DBName)
+		mask = append(mask, field.Name)
+	}
+
+	// Keep the primary key so partial entities can be written back
+	for _, pk := range s.PrimaryFields {
+		if !mask.Has(pk.Name) {
+			columns = append(columns, pk.DBName)
+			mask = append(mask, pk.Name)
+		}
+	}
+
+	newRepo.loadedFields = mask
+	newRepo.db = newRepo.db.Select(columns)
+	return newRepo
+}
+
+// LoadedFields returns the fields a partial projection loads, or nil when
+// entities are loaded whole.
+func (r *GenericRepository[T]) LoadedFields() FieldMask {
+	return r.loadedFields
+}
+
+// loadedOnly restricts the entity writes of db to the loaded fields and the
+// auto-update timestamps when the repository loads partial entities.
+func (r *GenericRepository[T]) loadedOnly(db *gorm.DB) (*gorm.DB, error) {
+	if len(r.loadedFields) == 0 {
+		return db, nil
+	}
+	s, err := parseSchema(db, new(T))
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, field := range s.Fields {
+		if field.DBName != "" && (r.loadedFields.Has(field.Name) || field.AutoUpdateTime > 0) {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return db.Session(&gorm.Session{}).Select(columns), nil
+}
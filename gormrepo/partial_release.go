@@ -0,0 +1,5 @@
+//go:build !gormrepo_debug
+
+package gormrepo
+
+const partialGuards = false
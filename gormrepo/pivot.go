@@ -0,0 +1,143 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrNoCurrentResult = errors.New("no current result available")
+
+// PivotAssociation reads and writes the extra columns of a many2many join
+// table, such as the role on user_teams.
+type PivotAssociation struct {
+	db       *gorm.DB
+	ctx      context.Context
+	owner    reflect.Value
+	relation *schema.Relationship
+	err      error
+}
+
+// AssociationWithPivot gives access to the join table of the many2many
+// association name of the current result.
+func (r *GenericRepository[T]) AssociationWithPivot(name string) *PivotAssociation {
+	a := &PivotAssociation{db: r.db.Session(&gorm.Session{NewDB: true}), ctx: r.context()}
+	if r.currentResult == nil {
+		a.err = fmt.Errorf("%w: load the owner of %s first", ErrNoCurrentResult, name)
+		return a
+	}
+	a.owner = reflect.ValueOf(r.currentResult).Elem()
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		a.err = err
+		return a
+	}
+	rel, ok := s.Relationships.Relations[name]
+	if !ok || rel.Type != schema.Many2Many || rel.JoinTable == nil {
+		a.err = fmt.Errorf("%s is not a many2many association of %s", name, s.Name)
+		return a
+	}
+	a.relation = rel
+	return a
+}
+
+// AppendWithPivot links target to the owner with the given join table
+// columns. An existing link gets its columns updated.
+func (a *PivotAssociation) AppendWithPivot(target any, pivot map[string]any) error {
+	keys, err := a.keys(target)
+	if err != nil {
+		return err
+	}
+
+	row := make(map[string]any, len(keys)+len(pivot))
+	conflict := clause.OnConflict{DoNothing: len(pivot) == 0}
+	for _, key := range keys {
+		column := key.Column.(clause.Column)
+		row[column.Name] = key.Value
+		conflict.Columns = append(conflict.Columns, column)
+	}
+	columns := make([]string, 0, len(pivot))
+	for column, value := range pivot {
+		row[column] = value
+		columns = append(columns, column)
+	}
+	if len(columns) > 0 {
+		conflict.DoUpdates = clause.AssignmentColumns(columns)
+	}
+
+	return a.db.WithContext(a.ctx).Table(a.relation.JoinTable.Table).Clauses(conflict).Create(row).Error
+}
+
+// UpdatePivot changes join table columns of the link between the owner and target.
+func (a *PivotAssociation) UpdatePivot(target any, pivot map[string]any) error {
+	keys, err := a.keys(target)
+	if err != nil {
+		return err
+	}
+	query := a.db.WithContext(a.ctx).Table(a.relation.JoinTable.Table)
+	for _, key := range keys {
+		query = query.Where(key)
+	}
+	return query.Updates(pivot).Error
+}
+
+// Scan loads the associated rows together with the given join table columns
+// into dest, a slice of DTOs with fields for both.
+func (a *PivotAssociation) Scan(dest any, pivotColumns ...string) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	joinTable := a.relation.JoinTable.Table
+	target := a.relation.FieldSchema.Table
+	query := a.db.WithContext(a.ctx).Table(target)
+
+	selects := []string{a.db.Statement.Quote(target) + ".*"}
+	for _, column := range pivotColumns {
+		selects = append(selects, a.db.Statement.Quote(clause.Column{Table: joinTable, Name: column}))
+	}
+	query = query.Select(selects)
+
+	for _, ref := range a.relation.References {
+		if ref.OwnPrimaryKey {
+			value, _ := ref.PrimaryKey.ValueOf(a.ctx, a.owner)
+			query = query.Where(clause.Eq{Column: clause.Column{Table: joinTable, Name: ref.ForeignKey.DBName}, Value: value})
+			continue
+		}
+		on := fmt.Sprintf("JOIN %s ON %s = %s", a.db.Statement.Quote(joinTable),
+			a.db.Statement.Quote(clause.Column{Table: joinTable, Name: ref.ForeignKey.DBName}),
+			a.db.Statement.Quote(clause.Column{Table: target, Name: ref.PrimaryKey.DBName}))
+		query = query.Joins(on)
+	}
+
+	return query.Scan(dest).Error
+}
+
+// keys returns the join table conditions identifying the link to target.
+func (a *PivotAssociation) keys(target any) ([]clause.Eq, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	targetValue := reflect.Indirect(reflect.ValueOf(target))
+	if targetValue.Type() != a.relation.FieldSchema.ModelType {
+		return nil, fmt.Errorf("%s is not a %s", targetValue.Type(), a.relation.FieldSchema.Name)
+	}
+
+	keys := make([]clause.Eq, 0, len(a.relation.References))
+	for _, ref := range a.relation.References {
+		source := targetValue
+		if ref.OwnPrimaryKey {
+			source = a.owner
+		}
+		value, _ := ref.PrimaryKey.ValueOf(a.ctx, source)
+		keys = append(keys, clause.Eq{Column: clause.Column{Name: ref.ForeignKey.DBName}, Value: value})
+	}
+	return keys, nil
+}
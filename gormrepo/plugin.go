@@ -0,0 +1,143 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var ErrPluginOrder = errors.New("plugins can't be ordered")
+
+type pluginRegistration struct {
+	plugin gorm.Plugin
+	after  []string
+}
+
+type PluginOption func(*pluginRegistration)
+
+// InstallAfter installs the plugin after the named plugins, e.g. a metrics
+// plugin after dbresolver so it sees the resolved connections.
+func InstallAfter(names ...string) PluginOption {
+	return func(p *pluginRegistration) {
+		p.after = append(p.after, names...)
+	}
+}
+
+var (
+	registeredPlugins []pluginRegistration
+	installMu         sync.Mutex
+)
+
+// RegisterPlugin adds p to the gorm plugins InstallPlugins installs, in
+// registration order unless InstallAfter says otherwise. Registering a name
+// again replaces the previous registration.
+func RegisterPlugin(p gorm.Plugin, opts ...PluginOption) {
+	registration := pluginRegistration{plugin: p}
+	for _, opt := range opts {
+		opt(&registration)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for i := range registeredPlugins {
+		if registeredPlugins[i].plugin.Name() == p.Name() {
+			registeredPlugins[i] = registration
+			return
+		}
+	}
+	registeredPlugins = append(registeredPlugins, registration)
+}
+
+// InstallPlugins installs the registered plugins db doesn't have yet, each
+// after the ones it's ordered after.
+func InstallPlugins(db *gorm.DB) error {
+	registryMu.RLock()
+	registered := slices.Clone(registeredPlugins)
+	registryMu.RUnlock()
+
+	installMu.Lock()
+	defer installMu.Unlock()
+
+	ordered, err := orderPlugins(db, registered)
+	if err != nil {
+		return err
+	}
+	return usePlugins(db, ordered...)
+}
+
+// WithPlugins installs the registered plugins and then plugins into the
+// database of the repository, when it doesn't have them yet. Plugins hook
+// into the database, so every repository on it sees them.
+func (r *GenericRepository[T]) WithPlugins(plugins ...gorm.Plugin) *GenericRepository[T] {
+	if err := InstallPlugins(r.db); err != nil {
+		r.lastError = err
+		return r
+	}
+
+	installMu.Lock()
+	defer installMu.Unlock()
+	if err := usePlugins(r.db, plugins...); err != nil {
+		r.lastError = err
+	}
+	return r
+}
+
+// usePlugins installs the plugins db doesn't have yet. Callers hold
+// installMu.
+func usePlugins(db *gorm.DB, plugins ...gorm.Plugin) error {
+	for _, p := range plugins {
+		if _, ok := db.Plugins[p.Name()]; ok {
+			continue
+		}
+		if err := db.Use(p); err != nil {
+			return fmt.Errorf("installing plugin %s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// orderPlugins sorts registered so every plugin comes after the ones it's
+// ordered after. Those must be registered or already installed in db.
+func orderPlugins(db *gorm.DB, registered []pluginRegistration) ([]gorm.Plugin, error) {
+	byName := make(map[string]pluginRegistration, len(registered))
+	for _, p := range registered {
+		byName[p.plugin.Name()] = p
+	}
+
+	var ordered []gorm.Plugin
+	state := map[string]int{} // 1 while visiting, 2 once ordered
+	var visit func(p pluginRegistration) error
+	visit = func(p pluginRegistration) error {
+		name := p.plugin.Name()
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("%w: %s is ordered after itself", ErrPluginOrder, name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dep := range p.after {
+			if next, ok := byName[dep]; ok {
+				if err := visit(next); err != nil {
+					return err
+				}
+			} else if _, ok := db.Plugins[dep]; !ok {
+				return fmt.Errorf("%w: %s is ordered after %s, which isn't registered", ErrPluginOrder, name, dep)
+			}
+		}
+		state[name] = 2
+		ordered = append(ordered, p.plugin)
+		return nil
+	}
+
+	for _, p := range registered {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
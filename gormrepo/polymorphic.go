@@ -0,0 +1,113 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrNotPolymorphic = errors.New("no polymorphic association")
+
+// polymorphicRef identifies an owner through the type and ID columns of a
+// polymorphic model, e.g. commentable_type and commentable_id.
+type polymorphicRef struct {
+	typeField *schema.Field
+	idField   *schema.Field
+	typeValue string
+	idValue   any
+}
+
+// ForOwner restricts the query to the entities that belong to owner through a
+// polymorphic association.
+func (r *GenericRepository[T]) ForOwner(owner any) *GenericRepository[T] {
+	ref, err := r.polymorphicRef(owner)
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	r.db = r.db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: ref.typeField.DBName}, Value: ref.typeValue}).
+		Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: ref.idField.DBName}, Value: ref.idValue})
+	return r
+}
+
+// AttachTo makes the following creates fill in the polymorphic type and ID
+// columns that point to owner.
+func (r *GenericRepository[T]) AttachTo(owner any) *GenericRepository[T] {
+	ref, err := r.polymorphicRef(owner)
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	r.attachOwner = ref
+	return r
+}
+
+func (r *GenericRepository[T]) attach(target any) error {
+	if r.attachOwner == nil {
+		return nil
+	}
+	ref := r.attachOwner
+	return forEachEntity(target, func(entity reflect.Value) error {
+		if err := ref.typeField.Set(r.context(), entity, ref.typeValue); err != nil {
+			return err
+		}
+		return ref.idField.Set(r.context(), entity, ref.idValue)
+	})
+}
+
+// polymorphicRef resolves the columns pointing to owner. A polymorphic
+// association declared on the owner is used when there is one; otherwise
+// T must have a single XxxType/XxxID field pair and the type is the owner's
+// table name, as gorm does by default.
+func (r *GenericRepository[T]) polymorphicRef(owner any) (*polymorphicRef, error) {
+	ownerSchema, err := parseSchema(r.db, owner)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if ownerSchema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%w: %s has no primary key", ErrNotPolymorphic, ownerSchema.Name)
+	}
+
+	ownerValue := reflect.Indirect(reflect.ValueOf(owner))
+	idValue, _ := ownerSchema.PrioritizedPrimaryField.ValueOf(r.context(), ownerValue)
+
+	for _, rel := range ownerSchema.Relationships.Relations {
+		if rel.Polymorphic == nil || rel.FieldSchema.Table != s.Table {
+			continue
+		}
+		return &polymorphicRef{
+			typeField: s.LookUpField(rel.Polymorphic.PolymorphicType.Name),
+			idField:   s.LookUpField(rel.Polymorphic.PolymorphicID.Name),
+			typeValue: rel.Polymorphic.Value,
+			idValue:   idValue,
+		}, nil
+	}
+
+	var ref *polymorphicRef
+	for _, field := range s.Fields {
+		prefix, ok := strings.CutSuffix(field.Name, "Type")
+		if !ok || prefix == "" || field.DBName == "" {
+			continue
+		}
+		idField := s.LookUpField(prefix + "ID")
+		if idField == nil {
+			continue
+		}
+		if ref != nil {
+			return nil, fmt.Errorf("%w: %s has several candidates, declare the association on %s", ErrNotPolymorphic, s.Name, ownerSchema.Name)
+		}
+		ref = &polymorphicRef{typeField: field, idField: idField, typeValue: ownerSchema.Table, idValue: idValue}
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("%w: between %s and %s", ErrNotPolymorphic, s.Name, ownerSchema.Name)
+	}
+	return ref, nil
+}
@@ -0,0 +1,117 @@
+package gormrepo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// LoadStrategy selects how Preload loads an association.
+type LoadStrategy int
+
+const (
+	// BatchLoad loads the association with a separate query per level, the
+	// default. It suits list queries.
+	BatchLoad LoadStrategy = iota
+	// JoinLoad loads the association in the main query with a LEFT JOIN. It
+	// suits single-row lookups and only applies to has-one and belongs-to
+	// associations; others are still batch loaded.
+	JoinLoad
+)
+
+// PreloadStrategy sets how later Preload calls load association.
+func (r *GenericRepository[T]) PreloadStrategy(association string, strategy LoadStrategy) *GenericRepository[T] {
+	if r.loadStrategies == nil {
+		r.loadStrategies = make(map[string]LoadStrategy)
+	}
+	r.loadStrategies[association] = strategy
+	return r
+}
+
+// joinLoadable reports whether association can be loaded with a JOIN, which
+// requires every step of its path to point to a single row.
+func (r *GenericRepository[T]) joinLoadable(association string) bool {
+	if r.loadStrategies[association] != JoinLoad {
+		return false
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return false
+	}
+	for _, name := range strings.Split(association, ".") {
+		rel, ok := s.Relationships.Relations[name]
+		if !ok || (rel.Type != schema.HasOne && rel.Type != schema.BelongsTo) {
+			return false
+		}
+		s = rel.FieldSchema
+	}
+	return true
+}
+
+// PreloadInto loads associations for entities obtained elsewhere, such as
+// from a cache or raw SQL. The entities are looked up again by primary key
+// so each association is loaded in batches, as Preload does.
+func (r *GenericRepository[T]) PreloadInto(entities *[]T, associations ...string) error {
+	if entities == nil || len(*entities) == 0 || len(associations) == 0 {
+		return nil
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return fmt.Errorf("%s has no primary key", s.Name)
+	}
+
+	ctx := r.context()
+	ids := make([]interface{}, 0, len(*entities))
+	for i := range *entities {
+		id, _ := pk.ValueOf(ctx, reflect.ValueOf(&(*entities)[i]).Elem())
+		ids = append(ids, id)
+	}
+
+	query := r.owned(r.db.Session(&gorm.Session{NewDB: true}))
+	for _, association := range associations {
+		query = query.Preload(association)
+	}
+
+	var loaded []T
+	err = r.run(OpQuery, nil, func() error {
+		return query.Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: ids}).Find(&loaded).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[interface{}]reflect.Value, len(loaded))
+	for i := range loaded {
+		value := reflect.ValueOf(&loaded[i]).Elem()
+		id, _ := pk.ValueOf(ctx, value)
+		byID[id] = value
+	}
+
+	for i := range *entities {
+		entity := reflect.ValueOf(&(*entities)[i]).Elem()
+		id, _ := pk.ValueOf(ctx, entity)
+		source, ok := byID[id]
+		if !ok {
+			continue
+		}
+		for _, association := range associations {
+			name, _, _ := strings.Cut(association, ".")
+			rel, ok := s.Relationships.Relations[name]
+			if !ok {
+				continue
+			}
+			rel.Field.ReflectValueOf(ctx, entity).Set(rel.Field.ReflectValueOf(ctx, source))
+		}
+	}
+	return nil
+}
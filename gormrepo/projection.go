@@ -1,271 +1,410 @@
-package gormrepo
-
-import (
-	"fmt"
-	"reflect"
-	"strings"
-)
-
-func hasStructFields(dtoInterface interface{}) bool {
-	dtoType := reflect.TypeOf(dtoInterface)
-	if dtoType.Kind() == reflect.Ptr {
-		dtoType = dtoType.Elem()
-	}
-
-	for i := 0; i < dtoType.NumField(); i++ {
-		field := dtoType.Field(i)
-
-		fieldType := field.Type
-		if fieldType.Kind() == reflect.Ptr {
-			fieldType = fieldType.Elem()
-		}
-
-		if fieldType.Kind() == reflect.Struct && !isBasicType(fieldType) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func extractPreloadsFromDTO(dtoInterface interface{}) []string {
-	var preloads []string
-
-	dtoType := reflect.TypeOf(dtoInterface)
-	if dtoType.Kind() == reflect.Ptr {
-		dtoType = dtoType.Elem()
-	}
-
-	for i := 0; i < dtoType.NumField(); i++ {
-		field := dtoType.Field(i)
-
-		fieldType := field.Type
-		if fieldType.Kind() == reflect.Ptr {
-			fieldType = fieldType.Elem()
-		}
-
-		if fieldType.Kind() == reflect.Struct && !isBasicType(fieldType) {
-			preloadName := field.Tag.Get("preload")
-			if preloadName == "" {
-				preloadName = field.Name
-			}
-			preloads = append(preloads, preloadName)
-		}
-	}
-
-	return preloads
-}
-
-func isBasicType(t reflect.Type) bool {
-	switch t.Kind() {
-	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Float32, reflect.Float64, reflect.Bool:
-		return true
-	}
-	return false
-}
-
-func getColumnName(field reflect.StructField) string {
-	gormTag := field.Tag.Get("gorm")
-	if gormTag != "" {
-		parts := strings.Split(gormTag, ";")
-		for _, part := range parts {
-			if strings.HasPrefix(part, "column:") {
-				return strings.TrimPrefix(part, "column:")
-			}
-		}
-	}
-
-	return toSnakeCase(field.Name)
-}
-
-func toSnakeCase(str string) string {
-	var result strings.Builder
-	for i, r := range str {
-		if i > 0 && 'A' <= r && r <= 'Z' {
-			result.WriteRune('_')
-		}
-		result.WriteRune(r)
-	}
-	return strings.ToLower(result.String())
-}
-
-func createProjectionFromDTO(dtoInterface interface{}) []string {
-	dtoType := reflect.TypeOf(dtoInterface)
-
-	if dtoType.Kind() == reflect.Ptr {
-		dtoType = dtoType.Elem()
-	}
-
-	var fields []string
-
-	for i := 0; i < dtoType.NumField(); i++ {
-		field := dtoType.Field(i)
-
-		if !field.IsExported() {
-			continue
-		}
-
-		if isBasicType(field.Type) {
-			columnName := getColumnNameFromDTO(field)
-			fields = append(fields, columnName)
-		}
-	}
-
-	return fields
-}
-
-func getColumnNameFromDTO(field reflect.StructField) string {
-	if projection := field.Tag.Get("projection"); projection != "" {
-		return projection
-	}
-
-	if gormTag := field.Tag.Get("gorm"); gormTag != "" {
-		parts := strings.Split(gormTag, ";")
-		for _, part := range parts {
-			if strings.HasPrefix(part, "column:") {
-				return strings.TrimPrefix(part, "column:")
-			}
-		}
-	}
-
-	if jsonTag := field.Tag.Get("json"); jsonTag != "" && jsonTag != "-" {
-		return strings.Split(jsonTag, ",")[0]
-	}
-
-	return toSnakeCase(field.Name)
-}
-
-func mapEntityToDTO[T any](entity *T, dtoInterface interface{}) (interface{}, error) {
-	if entity == nil {
-		return nil, fmt.Errorf("entity cannot be nil")
-	}
-
-	dtoType := reflect.TypeOf(dtoInterface)
-	if dtoType.Kind() == reflect.Ptr {
-		dtoType = dtoType.Elem()
-	}
-
-	dtoValue := reflect.New(dtoType).Elem()
-	entityValue := reflect.ValueOf(entity).Elem()
-	entityType := reflect.TypeOf(*entity)
-
-	for i := 0; i < dtoType.NumField(); i++ {
-		dtoField := dtoType.Field(i)
-		dtoFieldValue := dtoValue.Field(i)
-
-		if !dtoFieldValue.CanSet() {
-			continue
-		}
-
-		var entityFieldValue reflect.Value
-
-		if entityValue.FieldByName(dtoField.Name).IsValid() {
-			entityFieldValue = entityValue.FieldByName(dtoField.Name)
-		} else {
-			columnName := getColumnName(dtoField)
-			for j := 0; j < entityType.NumField(); j++ {
-				entityField := entityType.Field(j)
-				if getColumnName(entityField) == columnName {
-					entityFieldValue = entityValue.Field(j)
-					break
-				}
-			}
-		}
-
-		if entityFieldValue.IsValid() {
-			if err := mapFieldValue(entityFieldValue, dtoFieldValue, dtoField); err != nil {
-				return nil, fmt.Errorf("error mapping field %s: %w", dtoField.Name, err)
-			}
-		}
-	}
-
-	return dtoValue.Addr().Interface(), nil
-}
-
-func mapFieldValue(entityFieldValue, dtoFieldValue reflect.Value, dtoField reflect.StructField) error {
-	if entityFieldValue.Type().ConvertibleTo(dtoFieldValue.Type()) {
-		dtoFieldValue.Set(entityFieldValue.Convert(dtoFieldValue.Type()))
-		return nil
-	}
-
-	if entityFieldValue.Kind() == reflect.Struct && dtoFieldValue.Kind() == reflect.Struct {
-		return mapStructToStruct(entityFieldValue, dtoFieldValue)
-	}
-
-	if entityFieldValue.Kind() == reflect.Ptr && !entityFieldValue.IsNil() &&
-		dtoFieldValue.Kind() == reflect.Struct && entityFieldValue.Elem().Kind() == reflect.Struct {
-		return mapStructToStruct(entityFieldValue.Elem(), dtoFieldValue)
-	}
-
-	if entityFieldValue.Kind() == reflect.Slice && dtoFieldValue.Kind() == reflect.Slice {
-		return mapSliceToSlice(entityFieldValue, dtoFieldValue)
-	}
-
-	return nil
-}
-
-func mapStructToStruct(sourceValue, destValue reflect.Value) error {
-	sourceType := sourceValue.Type()
-	destType := destValue.Type()
-
-	for i := 0; i < destType.NumField(); i++ {
-		destField := destType.Field(i)
-		destFieldValue := destValue.Field(i)
-
-		if !destFieldValue.CanSet() {
-			continue
-		}
-
-		var sourceFieldValue reflect.Value
-
-		if sourceValue.FieldByName(destField.Name).IsValid() {
-			sourceFieldValue = sourceValue.FieldByName(destField.Name)
-		} else {
-			destColumnName := getColumnName(destField)
-			for j := 0; j < sourceType.NumField(); j++ {
-				sourceField := sourceType.Field(j)
-				if getColumnName(sourceField) == destColumnName {
-					sourceFieldValue = sourceValue.Field(j)
-					break
-				}
-			}
-		}
-
-		if sourceFieldValue.IsValid() {
-			if err := mapFieldValue(sourceFieldValue, destFieldValue, destField); err != nil {
-				return fmt.Errorf("error mapping nested field %s: %w", destField.Name, err)
-			}
-		}
-	}
-
-	return nil
-}
-
-func mapSliceToSlice(sourceValue, destValue reflect.Value) error {
-	if sourceValue.Len() == 0 {
-		return nil
-	}
-
-	destElemType := destValue.Type().Elem()
-	sourceElemType := sourceValue.Type().Elem()
-
-	newSlice := reflect.MakeSlice(destValue.Type(), sourceValue.Len(), sourceValue.Len())
-
-	for i := 0; i < sourceValue.Len(); i++ {
-		sourceElem := sourceValue.Index(i)
-		destElem := newSlice.Index(i)
-
-		if sourceElemType.Kind() == reflect.Struct && destElemType.Kind() == reflect.Struct {
-			if err := mapStructToStruct(sourceElem, destElem); err != nil {
-				return fmt.Errorf("error mapping slice element %d: %w", i, err)
-			}
-		} else if sourceElem.Type().ConvertibleTo(destElem.Type()) {
-			destElem.Set(sourceElem.Convert(destElem.Type()))
-		}
-	}
-
-	destValue.Set(newSlice)
-	return nil
-}
+package gormrepo
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+func hasStructFields(dtoInterface interface{}) bool {
+	dtoType := reflect.TypeOf(dtoInterface)
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	for i := 0; i < dtoType.NumField(); i++ {
+		field := dtoType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && !isBasicType(fieldType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func extractPreloadsFromDTO(dtoInterface interface{}) []string {
+	var preloads []string
+
+	dtoType := reflect.TypeOf(dtoInterface)
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	for i := 0; i < dtoType.NumField(); i++ {
+		field := dtoType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && !isBasicType(fieldType) {
+			preloadName := field.Tag.Get("preload")
+			if preloadName == "" {
+				preloadName = field.Name
+			}
+			preloads = append(preloads, preloadName)
+		}
+	}
+
+	return preloads
+}
+
+func isBasicType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+func getColumnName(field reflect.StructField) string {
+	gormTag := field.Tag.Get("gorm")
+	if gormTag != "" {
+		parts := strings.Split(gormTag, ";")
+		for _, part := range parts {
+			if strings.HasPrefix(part, "column:") {
+				return strings.TrimPrefix(part, "column:")
+			}
+		}
+	}
+
+	return toSnakeCase(field.Name)
+}
+
+func toSnakeCase(str string) string {
+	var result strings.Builder
+	for i, r := range str {
+		if i > 0 && 'A' <= r && r <= 'Z' {
+			result.WriteRune('_')
+		}
+		result.WriteRune(r)
+	}
+	return strings.ToLower(result.String())
+}
+
+func createProjectionFromDTO(dtoInterface interface{}, tags []string) []string {
+	dtoType := reflect.TypeOf(dtoInterface)
+
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	var fields []string
+
+	for i := 0; i < dtoType.NumField(); i++ {
+		field := dtoType.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if isBasicType(field.Type) {
+			if columnName, ok := dtoFieldColumn(field, tags, getColumnNameFromDTO); ok {
+				fields = append(fields, columnName)
+			}
+		}
+	}
+
+	return fields
+}
+
+func getColumnNameFromDTO(field reflect.StructField) string {
+	if projection := field.Tag.Get("projection"); projection != "" {
+		return projection
+	}
+
+	if gormTag := field.Tag.Get("gorm"); gormTag != "" {
+		parts := strings.Split(gormTag, ";")
+		for _, part := range parts {
+			if strings.HasPrefix(part, "column:") {
+				return strings.TrimPrefix(part, "column:")
+			}
+		}
+	}
+
+	if jsonTag := field.Tag.Get("json"); jsonTag != "" && jsonTag != "-" {
+		return strings.Split(jsonTag, ",")[0]
+	}
+
+	return toSnakeCase(field.Name)
+}
+
+// dtoFieldColumn returns the column a DTO field maps to, or false when its
+// `dto:"-"` or `dto:",omit"` tag leaves it out. With a tag priority the
+// first tag naming a column wins; otherwise a dto tag comes first and
+// fallback is used.
+func dtoFieldColumn(field reflect.StructField, tags []string, fallback func(reflect.StructField) string) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("dto"), ",")
+	if name == "-" || slices.Contains(strings.Split(options, ","), "omit") {
+		return "", false
+	}
+
+	if tags == nil {
+		if name != "" {
+			return name, true
+		}
+		return fallback(field), true
+	}
+
+	for _, tag := range tags {
+		if column := tagColumn(field, tag); column != "" {
+			return column, true
+		}
+	}
+	return toSnakeCase(field.Name), true
+}
+
+// tagColumn reads the column named by one tag of a field.
+func tagColumn(field reflect.StructField, tag string) string {
+	value := field.Tag.Get(tag)
+	switch tag {
+	case "gorm":
+		for _, part := range strings.Split(value, ";") {
+			if strings.HasPrefix(part, "column:") {
+				return strings.TrimPrefix(part, "column:")
+			}
+		}
+		return ""
+	case "projection":
+		return value
+	}
+
+	// dto, json and other encoding-style tags
+	name, _, _ := strings.Cut(value, ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func mapEntityToDTO[T any](entity *T, dtoInterface interface{}, tags []string) (interface{}, error) {
+	if entity == nil {
+		return nil, fmt.Errorf("entity cannot be nil")
+	}
+
+	dtoType := reflect.TypeOf(dtoInterface)
+	if dtoType.Kind() == reflect.Ptr {
+		dtoType = dtoType.Elem()
+	}
+
+	dtoValue := reflect.New(dtoType).Elem()
+	entityValue := reflect.ValueOf(entity).Elem()
+	entityType := reflect.TypeOf(*entity)
+
+	for i := 0; i < dtoType.NumField(); i++ {
+		dtoField := dtoType.Field(i)
+		dtoFieldValue := dtoValue.Field(i)
+
+		if !dtoFieldValue.CanSet() {
+			continue
+		}
+
+		columnName, ok := dtoFieldColumn(dtoField, tags, getColumnName)
+		if !ok {
+			continue
+		}
+
+		var entityFieldValue reflect.Value
+
+		if entityValue.FieldByName(dtoField.Name).IsValid() {
+			entityFieldValue = entityValue.FieldByName(dtoField.Name)
+		} else {
+			for j := 0; j < entityType.NumField(); j++ {
+				entityField := entityType.Field(j)
+				if getColumnName(entityField) == columnName {
+					entityFieldValue = entityValue.Field(j)
+					break
+				}
+			}
+		}
+
+		if entityFieldValue.IsValid() {
+			if err := mapFieldValue(entityFieldValue, dtoFieldValue, dtoField, tags); err != nil {
+				return nil, fmt.Errorf("error mapping field %s: %w", dtoField.Name, err)
+			}
+		}
+	}
+
+	return dtoValue.Addr().Interface(), nil
+}
+
+func mapFieldValue(entityFieldValue, dtoFieldValue reflect.Value, dtoField reflect.StructField, tags []string) error {
+	if entityFieldValue.Type() == dtoFieldValue.Type() {
+		dtoFieldValue.Set(entityFieldValue)
+		return nil
+	}
+
+	if ok, err := convertField(entityFieldValue, dtoFieldValue); ok || err != nil {
+		return err
+	}
+
+	// Custom types go through their own representation before plain
+	// conversions, which would turn a net.IP into a string of raw bytes.
+	if ok, err := passThroughField(entityFieldValue, dtoFieldValue); ok || err != nil {
+		return err
+	}
+
+	if decimalField(entityFieldValue, dtoFieldValue) {
+		return nil
+	}
+
+	if entityFieldValue.Type().ConvertibleTo(dtoFieldValue.Type()) {
+		dtoFieldValue.Set(entityFieldValue.Convert(dtoFieldValue.Type()))
+		return nil
+	}
+
+	if entityFieldValue.Kind() == reflect.Struct && dtoFieldValue.Kind() == reflect.Struct {
+		return mapStructToStruct(entityFieldValue, dtoFieldValue, tags)
+	}
+
+	if entityFieldValue.Kind() == reflect.Ptr && !entityFieldValue.IsNil() &&
+		dtoFieldValue.Kind() == reflect.Struct && entityFieldValue.Elem().Kind() == reflect.Struct {
+		return mapStructToStruct(entityFieldValue.Elem(), dtoFieldValue, tags)
+	}
+
+	if entityFieldValue.Kind() == reflect.Slice && dtoFieldValue.Kind() == reflect.Slice {
+		return mapSliceToSlice(entityFieldValue, dtoFieldValue, tags)
+	}
+
+	return nil
+}
+
+func mapStructToStruct(sourceValue, destValue reflect.Value, tags []string) error {
+	sourceType := sourceValue.Type()
+	destType := destValue.Type()
+
+	for i := 0; i < destType.NumField(); i++ {
+		destField := destType.Field(i)
+		destFieldValue := destValue.Field(i)
+
+		if !destFieldValue.CanSet() {
+			continue
+		}
+
+		destColumnName, ok := dtoFieldColumn(destField, tags, getColumnName)
+		if !ok {
+			continue
+		}
+
+		var sourceFieldValue reflect.Value
+
+		if sourceValue.FieldByName(destField.Name).IsValid() {
+			sourceFieldValue = sourceValue.FieldByName(destField.Name)
+		} else {
+			for j := 0; j < sourceType.NumField(); j++ {
+				sourceField := sourceType.Field(j)
+				if getColumnName(sourceField) == destColumnName {
+					sourceFieldValue = sourceValue.Field(j)
+					break
+				}
+			}
+		}
+
+		if sourceFieldValue.IsValid() {
+			if err := mapFieldValue(sourceFieldValue, destFieldValue, destField, tags); err != nil {
+				return fmt.Errorf("error mapping nested field %s: %w", destField.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func mapSliceToSlice(sourceValue, destValue reflect.Value, tags []string) error {
+	if sourceValue.Len() == 0 {
+		return nil
+	}
+
+	destElemType := destValue.Type().Elem()
+	sourceElemType := sourceValue.Type().Elem()
+
+	newSlice := reflect.MakeSlice(destValue.Type(), sourceValue.Len(), sourceValue.Len())
+
+	for i := 0; i < sourceValue.Len(); i++ {
+		sourceElem := sourceValue.Index(i)
+		destElem := newSlice.Index(i)
+
+		if sourceElemType.Kind() == reflect.Struct && destElemType.Kind() == reflect.Struct {
+			if err := mapStructToStruct(sourceElem, destElem, tags); err != nil {
+				return fmt.Errorf("error mapping slice element %d: %w", i, err)
+			}
+		} else if sourceElem.Type().ConvertibleTo(destElem.Type()) {
+			destElem.Set(sourceElem.Convert(destElem.Type()))
+		}
+	}
+
+	destValue.Set(newSlice)
+	return nil
+}
+
+// passThroughField maps custom types through the interfaces they implement
+// for the database or for text: driver.Valuer into sql.Scanner and
+// encoding.TextMarshaler into encoding.TextUnmarshaler or a string, and back.
+func passThroughField(src, dst reflect.Value) (bool, error) {
+	if !src.CanInterface() || (src.Kind() == reflect.Ptr && src.IsNil()) {
+		return false, nil
+	}
+	source := src.Interface()
+	target := dst.Addr().Interface()
+
+	if text, ok := source.(encoding.TextMarshaler); ok {
+		switch t := target.(type) {
+		case encoding.TextUnmarshaler:
+			b, err := text.MarshalText()
+			if err != nil {
+				return true, err
+			}
+			return true, t.UnmarshalText(b)
+		}
+		if dst.Kind() == reflect.String {
+			b, err := text.MarshalText()
+			if err != nil {
+				return true, err
+			}
+			dst.SetString(string(b))
+			return true, nil
+		}
+	}
+
+	if valuer, ok := source.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return true, err
+		}
+		if scanner, ok := target.(sql.Scanner); ok {
+			return true, scanner.Scan(value)
+		}
+		if value == nil {
+			return true, nil
+		}
+		v := reflect.ValueOf(value)
+		if v.Type().ConvertibleTo(dst.Type()) {
+			dst.Set(v.Convert(dst.Type()))
+			return true, nil
+		}
+		return false, nil
+	}
+
+	if src.Kind() == reflect.String {
+		switch t := target.(type) {
+		case encoding.TextUnmarshaler:
+			return true, t.UnmarshalText([]byte(src.String()))
+		case sql.Scanner:
+			return true, t.Scan(src.String())
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,141 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrUnpairedAmount is returned when an amount paired with a unit column is
+// compared without its unit.
+var ErrUnpairedAmount = errors.New("amount compared without its unit")
+
+// Quantity is an amount with its unit, e.g. a price with its currency or a
+// weight with its unit of measure. Amount is the exact decimal text.
+type Quantity struct {
+	Amount string
+	Unit   string
+}
+
+func (q Quantity) String() string {
+	return q.Amount + " " + q.Unit
+}
+
+// WhereQuantityGt and the following helpers compare an amount field with
+// amount in unit. The amount field names its unit field in a pair tag:
+//
+//	Price         decimal.Decimal `pair:"PriceCurrency"`
+//	PriceCurrency string
+//
+// Rows in other units never match; WhereAmountGt and the like reject paired
+// amounts, which they would compare across units.
+func (r *GenericRepository[T]) WhereQuantityGt(field string, amount any, unit string) *GenericRepository[T] {
+	return r.whereQuantity(field, ">", amount, unit)
+}
+
+func (r *GenericRepository[T]) WhereQuantityGte(field string, amount any, unit string) *GenericRepository[T] {
+	return r.whereQuantity(field, ">=", amount, unit)
+}
+
+func (r *GenericRepository[T]) WhereQuantityLt(field string, amount any, unit string) *GenericRepository[T] {
+	return r.whereQuantity(field, "<", amount, unit)
+}
+
+func (r *GenericRepository[T]) WhereQuantityLte(field string, amount any, unit string) *GenericRepository[T] {
+	return r.whereQuantity(field, "<=", amount, unit)
+}
+
+func (r *GenericRepository[T]) whereQuantity(field, op string, amount any, unit string) *GenericRepository[T] {
+	amountField, unitField, err := r.quantityFields(field)
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	r.db = r.db.Where(clause.Eq{Column: clause.Column{Name: unitField.DBName}, Value: unit})
+	return r.compareAmount(amountField.DBName, op, amount)
+}
+
+// SumQuantities totals the paired amount field of the matching rows per
+// unit, ordered by unit, instead of adding amounts of different units.
+func (r *GenericRepository[T]) SumQuantities(field string) ([]Quantity, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	amountField, unitField, err := r.quantityFields(field)
+	if err != nil {
+		return nil, err
+	}
+	unit := clause.Column{Name: unitField.DBName}
+
+	var rows []struct {
+		Unit   string
+		Amount string
+	}
+	err = r.run(OpQuery, nil, func() error {
+		return r.readDB(r.db.Model(new(T))).
+			Select("? AS unit, SUM(?) AS amount", unit, clause.Column{Name: amountField.DBName}).
+			Clauses(clause.GroupBy{Columns: []clause.Column{unit}}).
+			Order(clause.OrderByColumn{Column: unit}).
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]Quantity, len(rows))
+	for i, row := range rows {
+		totals[i] = Quantity{Amount: row.Amount, Unit: row.Unit}
+	}
+	return totals, nil
+}
+
+// QuantityOf returns the paired amount field of entity with its unit.
+func (r *GenericRepository[T]) QuantityOf(entity *T, field string) (Quantity, error) {
+	amountField, unitField, err := r.quantityFields(field)
+	if err != nil {
+		return Quantity{}, err
+	}
+	ctx := r.context()
+	value := reflect.ValueOf(entity).Elem()
+
+	amount, _ := amountField.ValueOf(ctx, value)
+	text, err := decimalText(columnValue(amount))
+	if err != nil {
+		return Quantity{}, err
+	}
+	unit, _ := unitField.ValueOf(ctx, value)
+	return Quantity{Amount: text, Unit: fmt.Sprint(columnValue(unit))}, nil
+}
+
+func (r *GenericRepository[T]) quantityFields(field string) (*schema.Field, *schema.Field, error) {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, nil, err
+	}
+	amount := lookUpFieldFold(s, field)
+	if amount == nil || amount.DBName == "" {
+		return nil, nil, fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, field)
+	}
+	pair := amount.Tag.Get("pair")
+	if pair == "" {
+		return nil, nil, fmt.Errorf("%s.%s has no pair tag naming its unit field", s.Name, amount.Name)
+	}
+	unit := lookUpFieldFold(s, pair)
+	if unit == nil || unit.DBName == "" {
+		return nil, nil, fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, pair)
+	}
+	return amount, unit, nil
+}
+
+// pairedAmount reports whether column is an amount paired with a unit.
+func (r *GenericRepository[T]) pairedAmount(column string) bool {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return false
+	}
+	field := s.LookUpField(column)
+	return field != nil && field.DBName == column && field.Tag.Get("pair") != ""
+}
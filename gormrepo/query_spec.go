@@ -0,0 +1,200 @@
+package gormrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnserializableQuery is returned by Marshal when the chain has
+// conditions or orders that aren't part of its specification.
+var ErrUnserializableQuery = errors.New("query has conditions outside its specification")
+
+// QuerySpec is the serializable specification of a chain built with Filter,
+// SortBy, ProjectFromFieldSet, Limit and Offset. It names fields and
+// operators, never SQL.
+type QuerySpec struct {
+	Filters []FilterSpec `json:"filters,omitempty"`
+	Sort    []SortSpec   `json:"sort,omitempty"`
+	Fields  []string     `json:"fields,omitempty"`
+	Limit   int          `json:"limit,omitempty"`
+	Offset  int          `json:"offset,omitempty"`
+}
+
+type FilterSpec struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value any    `json:"value,omitempty"`
+}
+
+type SortSpec struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// Filter adds the condition field op value, where op is one of eq, ne, gt,
+// gte, lt, lte, in, like, null and notnull. Unlike Where, it is part of the
+// query specification returned by Marshal.
+func (r *GenericRepository[T]) Filter(field, op string, value any) *GenericRepository[T] {
+	columns, err := r.columnsOf([]string{field})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: columns[0]}
+
+	var condition clause.Expression
+	switch op {
+	case "eq":
+		condition = clause.Eq{Column: column, Value: value}
+	case "ne":
+		condition = clause.Neq{Column: column, Value: value}
+	case "gt":
+		condition = clause.Gt{Column: column, Value: value}
+	case "gte":
+		condition = clause.Gte{Column: column, Value: value}
+	case "lt":
+		condition = clause.Lt{Column: column, Value: value}
+	case "lte":
+		condition = clause.Lte{Column: column, Value: value}
+	case "in":
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice {
+			r.lastError = fmt.Errorf("filter %s in needs a list, got %T", field, value)
+			return r
+		}
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).Interface()
+		}
+		condition = clause.IN{Column: column, Values: values}
+	case "like":
+		condition = clause.Like{Column: column, Value: value}
+	case "null":
+		condition = clause.Eq{Column: column, Value: nil}
+	case "notnull":
+		condition = clause.Neq{Column: column, Value: nil}
+	default:
+		r.lastError = fmt.Errorf("unknown filter operator %q", op)
+		return r
+	}
+
+	r.db = r.db.Where(condition)
+	r.spec.Filters = append(slices.Clip(r.spec.Filters), FilterSpec{Field: field, Op: op, Value: value})
+	return r
+}
+
+// SortBy orders by field. Unlike Order, it is part of the query
+// specification returned by Marshal.
+func (r *GenericRepository[T]) SortBy(field string, desc bool) *GenericRepository[T] {
+	columns, err := r.columnsOf([]string{field})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	r.db = r.db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: columns[0]}, Desc: desc})
+	r.spec.Sort = append(slices.Clip(r.spec.Sort), SortSpec{Field: field, Desc: desc})
+	return r
+}
+
+// Marshal returns the query specification of the chain as JSON, for a
+// worker to run the same query later with Unmarshal. Chains with other
+// conditions or orders fail with ErrUnserializableQuery.
+func (r *GenericRepository[T]) Marshal() ([]byte, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if len(whereExpressions(r.db)) != len(r.spec.Filters) || len(orderColumns(r.db)) != len(r.spec.Sort) {
+		return nil, ErrUnserializableQuery
+	}
+
+	spec := r.spec
+	spec.Limit, _ = currentLimit(r.db)
+	spec.Offset = currentOffset(r.db)
+	return json.Marshal(spec)
+}
+
+// Unmarshal applies a query specification returned by Marshal to the
+// chain. Fields are validated against T and filter values decoded into the
+// field types.
+func (r *GenericRepository[T]) Unmarshal(data []byte) *GenericRepository[T] {
+	var spec struct {
+		QuerySpec
+		Filters []struct {
+			Field string          `json:"field"`
+			Op    string          `json:"op"`
+			Value json.RawMessage `json:"value"`
+		} `json:"filters"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		r.lastError = fmt.Errorf("decoding query specification: %w", err)
+		return r
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	for _, filter := range spec.Filters {
+		field := s.LookUpField(filter.Field)
+		if field == nil {
+			r.lastError = fmt.Errorf("%w: %s has no field %s", ErrUnknownField, s.Name, filter.Field)
+			return r
+		}
+
+		var value any
+		if len(filter.Value) > 0 {
+			typ := field.FieldType
+			if filter.Op == "in" {
+				typ = reflect.SliceOf(typ)
+			}
+			decoded := reflect.New(typ)
+			if err := json.Unmarshal(filter.Value, decoded.Interface()); err != nil {
+				r.lastError = fmt.Errorf("decoding %s filter value: %w", filter.Field, err)
+				return r
+			}
+			value = decoded.Elem().Interface()
+		}
+		r.Filter(filter.Field, filter.Op, value)
+	}
+	for _, sort := range spec.Sort {
+		r.SortBy(sort.Field, sort.Desc)
+	}
+	if len(spec.Fields) > 0 {
+		r.ProjectFromFieldSet(spec.Fields)
+	}
+	if spec.Limit > 0 {
+		r.Limit(spec.Limit)
+	}
+	if spec.Offset > 0 {
+		r.Offset(spec.Offset)
+	}
+	return r
+}
+
+func whereExpressions(db *gorm.DB) []clause.Expression {
+	if c, ok := db.Statement.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			return where.Exprs
+		}
+	}
+	return nil
+}
+
+func orderColumns(db *gorm.DB) []clause.OrderByColumn {
+	if c, ok := db.Statement.Clauses["ORDER BY"]; ok {
+		if orderBy, ok := c.Expression.(clause.OrderBy); ok {
+			if orderBy.Expression != nil {
+				return make([]clause.OrderByColumn, 1)
+			}
+			return orderBy.Columns
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// CountForQuota returns the number of rows of tenant matching filters. The
+// tenant is held by the field tagged quota:"tenant". Without filters the
+// usage counter maintained by EnforceQuota is returned when there is one,
+// instead of counting the rows.
+func (r *GenericRepository[T]) CountForQuota(tenant any, filters map[string]any) (int64, error) {
+	s, tenantField, err := r.quotaSchema()
+	if err != nil {
+		return 0, err
+	}
+	db := r.db.Session(&gorm.Session{NewDB: true})
+
+	if len(filters) == 0 {
+		var usage []int64
+		err := db.Model(&Counter{}).Where("name = ?", quotaCounter(s, tenant)).Pluck("value", &usage).Error
+		if err != nil {
+			return 0, err
+		}
+		if len(usage) > 0 {
+			return usage[0], nil
+		}
+	}
+
+	query := db.Model(new(T)).Where(clause.Eq{Column: clause.Column{Name: tenantField.DBName}, Value: tenant})
+	query = r.whereFilters(query, filters)
+	var count int64
+	err = query.Count(&count).Error
+	return count, err
+}
+
+// EnforceQuota limits every tenant to limit rows. Creates check and
+// increment the tenant's usage counter in the insert transaction and fail
+// with ErrQuotaExceeded past the limit. Deletes drop the counter, which is
+// counted again on the next create.
+func (r *GenericRepository[T]) EnforceQuota(limit int64) *GenericRepository[T] {
+	if _, _, err := r.quotaSchema(); err != nil {
+		r.lastError = err
+		return r
+	}
+	r.quotaLimit = limit
+	return r
+}
+
+func (r *GenericRepository[T]) quotaSchema() (*schema.Schema, *schema.Field, error) {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, nil, err
+	}
+	if field := tenantField(s); field != nil {
+		return s, field, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s has no field tagged quota:\"tenant\"", ErrUnknownField, s.Name)
+}
+
+// tenantField returns the field tagged quota:"tenant", which holds the
+// tenant of a row.
+func tenantField(s *schema.Schema) *schema.Field {
+	for _, field := range s.Fields {
+		if field.Tag.Get("quota") == "tenant" && field.DBName != "" {
+			return field
+		}
+	}
+	return nil
+}
+
+// withQuota wraps a create or delete of target with the quota bookkeeping.
+func (r *GenericRepository[T]) withQuota(kind OperationKind, target any, op func(db *gorm.DB) error) func(db *gorm.DB) error {
+	if r.quotaLimit <= 0 || (kind != OpCreate && kind != OpDelete) {
+		return op
+	}
+	s, tenantField, err := r.quotaSchema()
+	if err != nil {
+		return func(*gorm.DB) error { return err }
+	}
+
+	// Rows per tenant; nil when an entity has no tenant
+	var tenants map[any]int64
+	if target != nil {
+		tenants = map[any]int64{}
+		forEachEntity(target, func(entity reflect.Value) error {
+			if tenants == nil {
+				return nil
+			}
+			tenant, isZero := tenantField.ValueOf(r.context(), entity)
+			if isZero {
+				tenants = nil
+			} else {
+				tenants[tenant]++
+			}
+			return nil
+		})
+	}
+
+	return func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			counters := tx.Session(&gorm.Session{NewDB: true}).Model(&Counter{})
+			if kind == OpDelete {
+				if err := op(tx); err != nil {
+					return err
+				}
+				if tenants == nil {
+					return counters.Where("name LIKE ?", quotaCounter(s, "%")).Delete(&Counter{}).Error
+				}
+				for tenant := range tenants {
+					if err := counters.Where("name = ?", quotaCounter(s, tenant)).Delete(&Counter{}).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			for tenant, n := range tenants {
+				if err := r.reserveQuota(tx, s, tenantField, tenant, n); err != nil {
+					return err
+				}
+			}
+			return op(tx)
+		})
+	}
+}
+
+// reserveQuota adds n rows to the usage counter of tenant, creating it from
+// the current row count the first time.
+func (r *GenericRepository[T]) reserveQuota(tx *gorm.DB, s *schema.Schema, tenantField *schema.Field, tenant any, n int64) error {
+	db := tx.Session(&gorm.Session{NewDB: true})
+	name := quotaCounter(s, tenant)
+
+	reserve := func() (bool, error) {
+		res := db.Model(&Counter{}).
+			Where("name = ? AND value + ? <= ?", name, n, r.quotaLimit).
+			Update("value", gorm.Expr("value + ?", n))
+		return res.RowsAffected > 0, res.Error
+	}
+	usage := func() ([]int64, error) {
+		var usage []int64
+		err := db.Model(&Counter{}).Where("name = ?", name).Pluck("value", &usage).Error
+		return usage, err
+	}
+
+	reserved, err := reserve()
+	if err != nil || reserved {
+		return err
+	}
+	used, err := usage()
+	if err != nil {
+		return err
+	}
+	if len(used) == 0 {
+		var count int64
+		err := db.Model(new(T)).Where(clause.Eq{Column: clause.Column{Name: tenantField.DBName}, Value: tenant}).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		// Concurrent creates may race to start the counter; the losers
+		// reserve on the winner's
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Counter{Name: name, Value: count}).Error; err != nil {
+			return err
+		}
+		if reserved, err = reserve(); err != nil || reserved {
+			return err
+		}
+		if used, err = usage(); err != nil {
+			return err
+		}
+		if len(used) == 0 {
+			used = append(used, count)
+		}
+	}
+	return fmt.Errorf("%w: tenant %v has %d of %d rows", ErrQuotaExceeded, tenant, used[0], r.quotaLimit)
+}
+
+func quotaCounter(s *schema.Schema, tenant any) string {
+	return fmt.Sprintf("quota:%s:%v", s.Table, tenant)
+}
@@ -0,0 +1,67 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type quotaItem struct {
+	ID       int64
+	TenantID int64 `quota:"tenant"`
+	Name     string
+}
+
+func TestEnforceQuotaReservesInTheInsertTransaction(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	r := New[quotaItem](db).EnforceQuota(10)
+
+	if err := r.Create(&quotaItem{TenantID: 3, Name: "lamp"}).Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fake.ran()
+	want := []string{
+		"BEGIN",
+		`UPDATE "counters" SET "value"=value + ? WHERE name = ? AND value + ? <= ? [1 quota:quota_items:3 1 10]`,
+		`INSERT INTO "quota_items"`,
+		"COMMIT",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ran %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("ran %q, want %s", got[i], want[i])
+		}
+	}
+}
+
+func TestCountForQuotaReadsTheCounter(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	r := New[quotaItem](db)
+
+	fake.queue([]string{"value"}, []driver.Value{int64(4)})
+	count, err := r.CountForQuota(3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("got %d, want 4", count)
+	}
+	if got := fake.ranLike(`FROM "quota_items"`); len(got) != 0 {
+		t.Errorf("counted the rows with %q", got)
+	}
+
+	// Filters need the rows counted
+	fake.queue([]string{"count"}, []driver.Value{int64(2)})
+	if count, err = r.CountForQuota(3, map[string]any{"name": "lamp"}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("got %d, want 2", count)
+	}
+	if got := fake.ranLike(`FROM "quota_items"`); len(got) != 1 || !strings.Contains(got[0], `"tenant_id" = ?`) {
+		t.Errorf("ran %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package gormrepo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// WithRecursiveDepth preloads self-referential associations of T, such as
+// Manager or Reports, up to depth levels. Without names every
+// self-referential association is loaded. Entities that reappear among
+// their own ancestors are left out, so cyclic data (A friends B friends A)
+// doesn't repeat itself down to the last level.
+func (r *GenericRepository[T]) WithRecursiveDepth(depth int, associations ...string) *GenericRepository[T] {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	if len(associations) == 0 {
+		for _, rel := range s.Relationships.Relations {
+			if rel.FieldSchema.Table == s.Table {
+				associations = append(associations, rel.Name)
+			}
+		}
+	}
+
+	var relations []*schema.Relationship
+	for _, name := range associations {
+		rel, ok := s.Relationships.Relations[name]
+		if !ok || rel.FieldSchema.Table != s.Table {
+			r.lastError = fmt.Errorf("%s is not a self-referential association of %s", name, s.Name)
+			return r
+		}
+		relations = append(relations, rel)
+
+		if depth > 0 {
+			r.db = r.db.Preload(strings.TrimSuffix(strings.Repeat(name+".", depth), "."))
+		}
+	}
+
+	r.recursiveRelations = relations
+	return r
+}
+
+// pruneCycles removes the entities loaded by WithRecursiveDepth that are
+// ancestors of themselves.
+func (r *GenericRepository[T]) pruneCycles(entities ...*T) {
+	if len(r.recursiveRelations) == 0 {
+		return
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil || s.PrioritizedPrimaryField == nil {
+		return
+	}
+	for _, entity := range entities {
+		r.pruneEntity(s.PrioritizedPrimaryField, reflect.ValueOf(entity).Elem(), map[any]bool{})
+	}
+}
+
+func (r *GenericRepository[T]) pruneEntity(pk *schema.Field, entity reflect.Value, ancestors map[any]bool) {
+	ctx := r.context()
+	key, _ := pk.ValueOf(ctx, entity)
+	ancestors[key] = true
+	defer delete(ancestors, key)
+
+	visit := func(child reflect.Value) bool {
+		child = reflect.Indirect(child)
+		if !child.IsValid() {
+			return false
+		}
+		if childKey, _ := pk.ValueOf(ctx, child); ancestors[childKey] {
+			return false
+		}
+		r.pruneEntity(pk, child, ancestors)
+		return true
+	}
+
+	for _, rel := range r.recursiveRelations {
+		value := rel.Field.ReflectValueOf(ctx, entity)
+		switch value.Kind() {
+		case reflect.Ptr:
+			if !value.IsNil() && !visit(value) {
+				value.Set(reflect.Zero(value.Type()))
+			}
+		case reflect.Slice:
+			kept := reflect.MakeSlice(value.Type(), 0, value.Len())
+			for i := 0; i < value.Len(); i++ {
+				if visit(value.Index(i)) {
+					kept = reflect.Append(kept, value.Index(i))
+				}
+			}
+			value.Set(kept)
+		case reflect.Struct:
+			visit(value)
+		}
+	}
+}
+
+func pointersTo[T any](entities []T) []*T {
+	pointers := make([]*T, len(entities))
+	for i := range entities {
+		pointers[i] = &entities[i]
+	}
+	return pointers
+}
@@ -0,0 +1,78 @@
+package gormrepo
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DanglingReference is a value of a reference column with no matching row
+// in the referenced table.
+type DanglingReference struct {
+	Table  string
+	Column string
+	Target string // Referenced table and column, e.g. "users.id"
+	Value  any
+	Rows   int64 // Number of rows holding Value
+}
+
+func (d DanglingReference) String() string {
+	return fmt.Sprintf("%s.%s = %v references no %s (%d rows)", d.Table, d.Column, d.Value, d.Target, d.Rows)
+}
+
+// VerifyReferences reports the dangling values of T's reference columns,
+// for databases without foreign key constraints. A reference column names
+// the table and column it references in a ref tag, the column defaulting
+// to id:
+//
+//	OwnerID uint `ref:"users.id"`
+func VerifyReferences[T any](db *gorm.DB) ([]DanglingReference, error) {
+	s, err := parseSchema(db, new(T))
+	if err != nil {
+		return nil, err
+	}
+
+	var dangling []DanglingReference
+	for _, field := range s.Fields {
+		ref := field.Tag.Get("ref")
+		if ref == "" || field.DBName == "" {
+			continue
+		}
+		target, targetColumn, ok := strings.Cut(ref, ".")
+		if !ok {
+			targetColumn = "id"
+		}
+
+		column := clause.Column{Table: s.Table, Name: field.DBName}
+		referenced := clause.Column{Table: "ref__", Name: targetColumn}
+		var rows []map[string]any
+		err := db.Session(&gorm.Session{NewDB: true}).
+			Table(s.Table).
+			Select("? AS value, COUNT(*) AS dangling_rows", column).
+			Joins("LEFT JOIN ? AS ref__ ON ? = ?", clause.Table{Name: target}, referenced, column).
+			Where("? IS NOT NULL AND ? IS NULL", column, referenced).
+			Clauses(clause.GroupBy{Columns: []clause.Column{column}}).
+			Order(clause.OrderByColumn{Column: column}).
+			Find(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("verifying %s.%s: %w", s.Table, field.DBName, err)
+		}
+
+		for _, row := range rows {
+			value := row["value"]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			dangling = append(dangling, DanglingReference{
+				Table:  s.Table,
+				Column: field.DBName,
+				Target: target + "." + targetColumn,
+				Value:  value,
+				Rows:   toInt64(row["dangling_rows"]),
+			})
+		}
+	}
+	return dangling, nil
+}
@@ -0,0 +1,104 @@
+package gormrepo
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Refresh reloads the current database state of entity, and the given
+// associations, into the same pointer. It fails with ErrNotFound when the
+// row no longer exists. Fields a column policy hides are left as they are.
+func (r *GenericRepository[T]) Refresh(entity *T, associations ...string) *GenericRepository[T] {
+	s, err := parseSchema(r.db, entity)
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		r.lastError = fmt.Errorf("%s has no primary key", s.Name)
+		return r
+	}
+	id, _ := pk.ValueOf(r.context(), reflect.ValueOf(entity).Elem())
+
+	query := r.owned(r.db.Session(&gorm.Session{NewDB: true}))
+	for _, association := range associations {
+		query = query.Preload(association)
+	}
+
+	var fresh T
+	err = r.run(OpQuery, nil, func() error {
+		return r.redacted(query).Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: id}).Take(&fresh).Error
+	})
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+
+	r.afterLoad(&fresh)
+	if err := r.keepHidden(s, entity, &fresh); err != nil {
+		r.lastError = err
+		return r
+	}
+	*entity = fresh
+	r.currentResult = entity
+	return r
+}
+
+// IsStale reports whether the row of entity changed since it was loaded, by
+// reading only its version column, or its UpdatedAt timestamp when there is
+// no Version field. Timestamps count as changed when the stored one is later,
+// which tolerates databases storing them with less precision.
+func (r *GenericRepository[T]) IsStale(entity *T) (bool, error) {
+	s, err := parseSchema(r.db, entity)
+	if err != nil {
+		return false, err
+	}
+	pk := s.PrioritizedPrimaryField
+	if pk == nil {
+		return false, fmt.Errorf("%s has no primary key", s.Name)
+	}
+
+	field := s.LookUpField("Version")
+	if field == nil {
+		for _, f := range s.Fields {
+			if f.AutoUpdateTime != 0 {
+				field = f
+				break
+			}
+		}
+	}
+	if field == nil {
+		return false, fmt.Errorf("%s has neither a Version nor an UpdatedAt field", s.Name)
+	}
+
+	ctx := r.context()
+	current := reflect.ValueOf(entity).Elem()
+	id, _ := pk.ValueOf(ctx, current)
+
+	var stored T
+	err = r.run(OpQuery, nil, func() error {
+		return r.owned(r.db.Session(&gorm.Session{NewDB: true})).
+			Select(field.DBName).
+			Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: id}).
+			Take(&stored).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	mine, _ := field.ValueOf(ctx, current)
+	theirs, _ := field.ValueOf(ctx, reflect.ValueOf(&stored).Elem())
+	if t, ok := theirs.(time.Time); ok {
+		return t.After(mine.(time.Time)), nil
+	}
+	if t, ok := theirs.(*time.Time); ok {
+		m := mine.(*time.Time)
+		return t != nil && (m == nil || t.After(*m)), nil
+	}
+	return !reflect.DeepEqual(mine, theirs), nil
+}
@@ -0,0 +1,65 @@
+package gormrepo
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	registryMu sync.RWMutex
+	models     []reflect.Type
+)
+
+// RegisterModel adds T to the models EnsureMigrated manages. Registering a
+// model again has no effect.
+func RegisterModel[T any]() {
+	registerModel(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func registerModel(typ reflect.Type) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, model := range models {
+		if model == typ {
+			return
+		}
+	}
+	models = append(models, typ)
+}
+
+// registeredModels returns a new value of each registered model, in
+// registration order.
+func registeredModels() []any {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	values := make([]any, len(models))
+	for i, model := range models {
+		values[i] = reflect.New(model).Interface()
+	}
+	return values
+}
+
+// EnsureMigrated installs the registered plugins, auto-migrates the
+// registered models and their translation tables, then creates their
+// declared indexes and verifies the existing ones match.
+func EnsureMigrated(db *gorm.DB) error {
+	if err := InstallPlugins(db); err != nil {
+		return err
+	}
+
+	values := registeredModels()
+	if err := db.AutoMigrate(values...); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, model := range values {
+		errs = append(errs, ensureIndexes(db, model), migrateTranslations(db, model))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package gormrepo
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reorder rewrites positionColumn so the given ids come first, in order,
+// followed by the remaining rows of the chain's scope in their current order.
+// Positions are renumbered 1..n, closing any gaps. The rewrite goes through
+// negative values first so a unique index on the position never sees duplicates.
+func (r *GenericRepository[T]) Reorder(ids []int64, positionColumn string) *GenericRepository[T] {
+	if r.lastError != nil {
+		return r
+	}
+	pk := r.primaryKeyColumn()
+
+	var order []int64
+	reorder := func(tx *gorm.DB) error {
+		var existing []int64
+		err := tx.Model(new(T)).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: positionColumn}}).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: pk}}).
+			Pluck(pk, &existing).Error
+		if err != nil {
+			return err
+		}
+
+		inScope := make(map[int64]bool, len(existing))
+		for _, id := range existing {
+			inScope[id] = true
+		}
+
+		order = make([]int64, 0, len(existing))
+		listed := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			if !inScope[id] {
+				return fmt.Errorf("cannot reorder: id %d not found", id)
+			}
+			if !listed[id] {
+				listed[id] = true
+				order = append(order, id)
+			}
+		}
+		for _, id := range existing {
+			if !listed[id] {
+				order = append(order, id)
+			}
+		}
+		if len(order) == 0 {
+			return nil
+		}
+
+		var sql strings.Builder
+		args := []interface{}{clause.Column{Name: pk}}
+		sql.WriteString("CASE ?")
+		for i, id := range order {
+			sql.WriteString(" WHEN ? THEN ?")
+			args = append(args, id, -(i + 1))
+		}
+		sql.WriteString(" END")
+
+		target := clause.IN{Column: clause.Column{Name: pk}, Values: toInterfaces(order)}
+		if err := tx.Model(new(T)).Where(target).Update(positionColumn, gorm.Expr(sql.String(), args...)).Error; err != nil {
+			return err
+		}
+		return tx.Model(new(T)).Where(target).Update(positionColumn, gorm.Expr("- ?", clause.Column{Name: positionColumn})).Error
+	}
+
+	err := r.write(OpUpdate, nil, func(db *gorm.DB) error {
+		return db.Transaction(reorder)
+	})
+	if err == nil {
+		err = r.onCommit(func() error {
+			for _, id := range order {
+				r.uncacheID(id)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		r.lastError = err
+	}
+	return r
+}
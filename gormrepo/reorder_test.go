@@ -0,0 +1,61 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+type rankedItem struct {
+	ID       int64
+	Position int
+}
+
+func TestReorderRenumbersThroughNegativePositions(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	r := New[rankedItem](db).WithCache(NewMemoryCache(), time.Minute)
+
+	fake.queue([]string{"id", "position"}, []driver.Value{int64(2), 1})
+	if _, err := r.clone(db).FindByIDCached(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored in the order 1, 2, 3; 3 moves first
+	fake.queue([]string{"id"}, []driver.Value{int64(1)}, []driver.Value{int64(2)}, []driver.Value{int64(3)})
+	if err := r.Where("position > ?", 0).Reorder([]int64{3}, "position").Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fake.ranLike(`"ranked_items"`)[1:] // After the cached lookup
+	want := []string{
+		`SELECT "id" FROM "ranked_items" WHERE position > ? ORDER BY "position","id" [0]`,
+		`UPDATE "ranked_items" SET "position"=CASE "id" WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? END WHERE position > ? AND "id" IN (?,?,?) [3 -1 1 -2 2 -3 0 3 1 2]`,
+		`UPDATE "ranked_items" SET "position"=- "position" WHERE position > ? AND "id" IN (?,?,?) [0 3 1 2]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The moved rows are read again
+	fake.queue([]string{"id", "position"}, []driver.Value{int64(2), 3})
+	item, err := r.clone(db).FindByIDCached(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Position != 3 {
+		t.Errorf("got %+v", item)
+	}
+}
+
+func TestReorderRejectsUnknownIDs(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	fake.queue([]string{"id"}, []driver.Value{int64(1)})
+
+	if err := New[rankedItem](db).Reorder([]int64{9}, "position").Error(); err == nil || !strings.Contains(err.Error(), "id 9 not found") {
+		t.Fatalf("got %v", err)
+	}
+	if got := fake.ranLike("UPDATE"); len(got) != 0 {
+		t.Errorf("ran %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+package gormrepo
+
+import (
+	"gorm.io/gorm"
+)
+
+// ConsistencyToken identifies a position of the primary's write log. Reads
+// given a token only use the replica once it has replayed that far.
+type ConsistencyToken string
+
+// primaryOnly is the token used when the position of the primary can't be
+// read: reads given it always go to the primary.
+const primaryOnly ConsistencyToken = "primary"
+
+// WithReadReplica sends queries outside transactions to replica, while writes
+// keep using the repository's database.
+func (r *GenericRepository[T]) WithReadReplica(replica *gorm.DB) *GenericRepository[T] {
+	r.replica = replica
+	return r
+}
+
+// ConsistencyToken returns the token of the last write committed through the
+// repository, empty when there was none or no read replica is configured.
+func (r *GenericRepository[T]) ConsistencyToken() ConsistencyToken {
+	return r.writeToken
+}
+
+// WithConsistencyToken makes queries read from the primary until the replica
+// has caught up with token, so a user sees their own writes.
+func (r *GenericRepository[T]) WithConsistencyToken(token ConsistencyToken) *GenericRepository[T] {
+	r.readToken = token
+	return r
+}
+
+// recordPosition keeps the primary's position after a commit.
+func (r *GenericRepository[T]) recordPosition() {
+	if r.replica == nil {
+		return
+	}
+
+	db := r.db.Session(&gorm.Session{NewDB: true})
+	var query string
+	switch db.Dialector.Name() {
+	case "postgres":
+		query = "SELECT pg_current_wal_lsn()::text"
+	case "mysql":
+		query = "SELECT @@GLOBAL.gtid_executed"
+	default:
+		r.writeToken = primaryOnly
+		return
+	}
+
+	var position string
+	if err := db.Raw(query).Scan(&position).Error; err != nil || position == "" {
+		r.writeToken = primaryOnly
+		return
+	}
+	r.writeToken = ConsistencyToken(position)
+}
+
+// caughtUp reports whether the replica has replayed the read token's position.
+func (r *GenericRepository[T]) caughtUp() bool {
+	if r.readToken == "" {
+		return true
+	}
+	if r.readToken == primaryOnly {
+		return false
+	}
+
+	db := r.replica.Session(&gorm.Session{NewDB: true, Context: r.context()})
+	var query string
+	switch db.Dialector.Name() {
+	case "postgres":
+		query = "SELECT COALESCE(pg_last_wal_replay_lsn() >= CAST(? AS pg_lsn), true)"
+	case "mysql":
+		query = "SELECT GTID_SUBSET(?, @@GLOBAL.gtid_executed) = 1"
+	default:
+		return false
+	}
+
+	var ok bool
+	if err := db.Raw(query, string(r.readToken)).Scan(&ok).Error; err != nil {
+		return false
+	}
+	return ok
+}
+
+// readDB returns db set up to run a query on the replica when one is
+// configured and it is safe to do so.
+func (r *GenericRepository[T]) readDB(db *gorm.DB) *gorm.DB {
+	if r.replica == nil || inTransaction(db) || !r.caughtUp() {
+		return r.withOperationContext(db, r.operation)
+	}
+	tx := db.Session(&gorm.Session{Context: r.context()})
+	tx.Statement.ConnPool = r.replica.Statement.ConnPool
+	return r.withOperationContext(tx, r.operation)
+}
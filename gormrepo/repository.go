@@ -1,100 +1,303 @@
-package gormrepo
-
-import (
-	"context"
-
-	"gorm.io/gorm"
-)
-
-type BaseRepository[T any] interface {
-	Begin() (*gorm.DB, error)
-	Commit(tx *gorm.DB) error
-	Rollback(tx *gorm.DB) error
-
-	// Fluent methods - return *GenericRepository[T] for chaining
-	Create(entity *T) *GenericRepository[T]
-	CreateWithPreload(entity *T, associations ...string) *GenericRepository[T]
-	CreateWithAllAssociations(entity *T) *GenericRepository[T]
-	CreateBatch(entities *[]T) *GenericRepository[T]
-
-	Update(entity *T) *GenericRepository[T]
-	UpdateWithPreload(entity *T, fields ...string) *GenericRepository[T]
-	UpdateFields(entity *T, fields map[string]interface{}) *GenericRepository[T]
-
-	Delete(id int64) *GenericRepository[T]
-	DeleteEntity(entity *T) *GenericRepository[T]
-	DeleteBatch(entities *[]T) *GenericRepository[T]
-
-	FindByID(id int64) *GenericRepository[T]
-	FindAll() *GenericRepository[T]
-
-	Preload(associations ...string) *GenericRepository[T]
-	WithJoins(joins ...string) *GenericRepository[T]
-
-	Where(query interface{}, args ...interface{}) *GenericRepository[T]
-	Order(value interface{}) *GenericRepository[T]
-	Count(filters map[string]interface{}) (int64, error)
-	Exists(filters map[string]interface{}) (bool, error)
-
-	CreateWithContext(ctx context.Context, entity *T) *GenericRepository[T]
-	FindByIDWithContext(ctx context.Context, id int64) *GenericRepository[T]
-	FindOne(filters map[string]interface{}) *GenericRepository[T]
-
-	Limit(limit int) *GenericRepository[T]
-	Offset(offset int) *GenericRepository[T]
-	Paginate(page, pageSize int) *GenericRepository[T]
-
-	Transaction(fn func(tx *GenericRepository[T]) error) error
-	WithDB(db *gorm.DB) *GenericRepository[T]
-	Select(query interface{}, args ...interface{}) *GenericRepository[T]
-	Group(name string) *GenericRepository[T]
-	Having(query interface{}, args ...interface{}) *GenericRepository[T]
-	Or(query interface{}, args ...interface{}) *GenericRepository[T]
-	Not(query interface{}, args ...interface{}) *GenericRepository[T]
-
-	// Finalizer methods - execute the query and return the result
-	First() (*T, error) // Returns first entity found
-	Get() (*[]T, error) // Returns slice of entities
-	One() (*T, error)   // Returns one entity or error if not exactly one found
-	// FindFirst() (*T, error) // Alias for First() for compatibility
-
-	// Projection methods - return repository configured to use projection
-	// ProjectTo(dtoInterface interface{}) *GenericRepository[T]
-	// ProjectToPartial(dtoInterface interface{}) *GenericRepository[T] // Returns entity with only projection fields filled
-	ProjectToDTO(dtoInterface interface{}) *GenericRepository[T] // Returns only DTO, not complete entity
-
-	// Conversion methods for real DTO - works with repository current result
-	Project() (interface{}, error)      // Converts currentResult to real DTO using configured projection
-	ProjectSlice() (interface{}, error) // Converts currentSlice to slice of real DTOs using configured projection
-
-	// Static conversion methods (for compatibility)
-	ProjectEntity(entity *T, dtoInterface interface{}) (interface{}, error)
-	ProjectEntitySlice(entities *[]T, dtoInterface interface{}) (interface{}, error)
-
-	// Helper methods to check state
-	HasError() bool
-	Error() error
-	Result() (*T, error)    // Returns currentResult and lastError
-	Results() (*[]T, error) // Returns currentSlice and lastError
-	Execute() error         // Finalizes operation and returns only error
-}
-type GenericRepository[T any] struct {
-	db             *gorm.DB
-	projection     interface{} // Stores DTO type for projection
-	projectionMode string      // "full", "partial", "dto"
-	currentResult  *T          // Stores current result for chaining
-	currentSlice   *[]T        // Stores slice of results for chaining
-	lastError      error       // Stores last error that occurred
-}
-
-func New[T any](db *gorm.DB) *GenericRepository[T] {
-	if db == nil {
-		panic("database not initialized")
-	}
-	return &GenericRepository[T]{
-		db:            db,
-		currentResult: nil,
-		currentSlice:  nil,
-		lastError:     nil,
-	}
-}
+package gormrepo
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrNotFound is returned when a lookup matches no row. It is gorm's
+// ErrRecordNotFound, so either can be used with errors.Is.
+var ErrNotFound = gorm.ErrRecordNotFound
+
+type BaseRepository[T any] interface {
+	Begin() (*gorm.DB, error)
+	Commit(tx *gorm.DB) error
+	Rollback(tx *gorm.DB) error
+
+	// Fluent methods - return *GenericRepository[T] for chaining
+	Create(entity *T) *GenericRepository[T]
+	CreateWithPreload(entity *T, associations ...string) *GenericRepository[T]
+	CreateWithAllAssociations(entity *T) *GenericRepository[T]
+	CreateBatch(entities *[]T) *GenericRepository[T]
+	CreateBatchIgnoreConflicts(entities *[]T) (*BatchInsertResult[T], error)
+	CreateIdempotent(entity *T, key string) *GenericRepository[T]
+	WithIDGenerator(generator IDGenerator) *GenericRepository[T] // Generates empty string/UUID primary keys on create
+	WithIDAllocator(allocator IDAllocator) *GenericRepository[T] // Allocates zero integer primary keys on create
+	WithClock(clock Clock) *GenericRepository[T]
+	TouchTimestamps(enabled bool) *GenericRepository[T]
+	WithLocation(loc *time.Location) *GenericRepository[T]
+
+	Update(entity *T) *GenericRepository[T]
+	UpdateWithPreload(entity *T, fields ...string) *GenericRepository[T]
+	Refresh(entity *T, associations ...string) *GenericRepository[T]
+	IsStale(entity *T) (bool, error)
+	UpdateFields(entity *T, fields map[string]interface{}) *GenericRepository[T]
+	UpdateBatchFields(updates map[int64]map[string]interface{}) *GenericRepository[T]
+	UpdateWhere(fields map[string]interface{}) *GenericRepository[T]
+	Reorder(ids []int64, positionColumn string) *GenericRepository[T]
+
+	Upsert(entity *T) *GenericRepository[T]
+	UpsertBatch(entities *[]T) *GenericRepository[T]
+	OnConflictColumns(columns ...string) *UpsertBuilder[T] // Configure conflict target and updated columns
+
+	Delete(id int64) *GenericRepository[T]
+	DeleteEntity(entity *T) *GenericRepository[T]
+	DeleteBatch(entities *[]T) *GenericRepository[T]
+	DeleteWhere() *GenericRepository[T]
+	StrictWrites() *GenericRepository[T] // UpdateWhere/DeleteWhere require WHERE conditions
+
+	FindByID(id int64) *GenericRepository[T]
+	FindAll() *GenericRepository[T]
+
+	Preload(associations ...string) *GenericRepository[T]
+	PreloadStrategy(association string, strategy LoadStrategy) *GenericRepository[T]
+	WithRecursiveDepth(depth int, associations ...string) *GenericRepository[T]
+	PreloadInto(entities *[]T, associations ...string) error
+	WithJoins(joins ...string) *GenericRepository[T]
+	InnerJoin(table interface{}, on string, args ...interface{}) *GenericRepository[T] // table is a name or a model
+	LeftJoin(table interface{}, on string, args ...interface{}) *GenericRepository[T]
+	RightJoin(table interface{}, on string, args ...interface{}) *GenericRepository[T]
+	JoinAssociation(association string, conds ...interface{}) *GenericRepository[T]
+	InnerJoinAssociation(association string, conds ...interface{}) *GenericRepository[T]
+	ForOwner(owner any) *GenericRepository[T]
+	AttachTo(owner any) *GenericRepository[T]
+	AssociationWithPivot(name string) *PivotAssociation
+
+	Where(query interface{}, args ...interface{}) *GenericRepository[T]
+	WhereAny(filters map[string]interface{}) *GenericRepository[T]
+	WhereAmountGt(column string, amount any) *GenericRepository[T]
+	WhereAmountGte(column string, amount any) *GenericRepository[T]
+	WhereAmountLt(column string, amount any) *GenericRepository[T]
+	WhereAmountLte(column string, amount any) *GenericRepository[T]
+	WhereTupleIn(columns []string, values [][]any) *GenericRepository[T]
+	WhereIEquals(column string, value string) *GenericRepository[T]
+	WhereILike(column string, pattern string) *GenericRepository[T]
+	WithSearchCollation(collation string) *GenericRepository[T]
+	WithUnaccent() *GenericRepository[T]
+	WhereGroup(fn func(g *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T]
+	OrGroup(fn func(g *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T]
+	Scopes(fns ...func(*gorm.DB) *gorm.DB) *GenericRepository[T]
+	When(cond bool, fn func(r *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T]   // Applies fn only when cond is true
+	Unless(cond bool, fn func(r *GenericRepository[T]) *GenericRepository[T]) *GenericRepository[T] // Applies fn only when cond is false
+	Order(value interface{}) *GenericRepository[T]
+	Count(filters map[string]interface{}) (int64, error)
+	Exists(filters map[string]interface{}) (bool, error)
+	NextCounter(name string) (int64, error) // Gapless when called inside a transaction
+
+	CreateWithContext(ctx context.Context, entity *T) *GenericRepository[T]
+	FindByIDWithContext(ctx context.Context, id int64) *GenericRepository[T]
+	WithCache(cache Cache, ttl time.Duration) *GenericRepository[T]
+	WithNegativeCache(ttl time.Duration) *GenericRepository[T] // Caches FindByIDCached misses for ttl
+	FindByIDCached(id int64) (*T, error)
+	FindOne(filters map[string]interface{}) *GenericRepository[T]
+
+	Limit(limit int) *GenericRepository[T]
+	Offset(offset int) *GenericRepository[T]
+	Paginate(page, pageSize int) *GenericRepository[T]
+	WithMaxRows(n int) *GenericRepository[T]                      // Get fails with ErrTooManyRows above n rows
+	WithDefaultLimit(n int) *GenericRepository[T]                 // LIMIT applied when the chain sets none
+	WithMaxOffset(n int) *GenericRepository[T]                    // List queries fail with ErrDeepPagination past n rows
+	ListPage(req PageTokenRequest) (*PageTokenResponse[T], error) // AIP-158 page_size/page_token listing
+	Connection(first int, after string, last int, before string) (*Connection[T], error)
+
+	Transaction(fn func(tx *GenericRepository[T]) error) error
+	TransactionCtx(ctx context.Context, fn func(tx *GenericRepository[T]) error, opts TxOptions) error
+	WithDeferredConstraints(fn func(tx *GenericRepository[T]) error) error
+	OnEvent(handlers ...EventHandler) *GenericRepository[T]
+	WithOutbox() *GenericRepository[T]
+	AfterCommit(hooks ...CommitHook[T]) *GenericRepository[T]
+	AfterRollback(hooks ...CommitHook[T]) *GenericRepository[T]
+	WithStateMachine(column string, transitions map[string][]string) *GenericRepository[T]
+	Use(mw ...Middleware) *GenericRepository[T]
+	WithAuthorizer(fn Authorizer) *GenericRepository[T]
+	WithColumnPolicy(policy ColumnPolicy) *GenericRepository[T]
+	WithOwnership(field string) *GenericRepository[T]
+	EnforceQuota(limit int64) *GenericRepository[T]
+	CountForQuota(tenant any, filters map[string]any) (int64, error)
+	FindConflicts(entities *[]T, uniqueColumns ...string) ([]Conflict[T], error)
+	FindDuplicates(columns ...string) ([]DuplicateGroup, error)
+	DeduplicateKeeping(strategy KeepStrategy, columns ...string) (int64, error)
+	ETag(entity *T) (string, error)
+	WithBlobStore(store BlobStore) *GenericRepository[T]
+	WithFileStorage(storage FileStorage) *GenericRepository[T]
+	WithLocale(locale string) *GenericRepository[T]
+	WhereQuantityGt(field string, amount any, unit string) *GenericRepository[T]
+	WhereQuantityGte(field string, amount any, unit string) *GenericRepository[T]
+	WhereQuantityLt(field string, amount any, unit string) *GenericRepository[T]
+	WhereQuantityLte(field string, amount any, unit string) *GenericRepository[T]
+	SumQuantities(field string) ([]Quantity, error)
+	QuantityOf(entity *T, field string) (Quantity, error)
+	WithDefaultOrder(order string) *GenericRepository[T]
+	Unordered() *GenericRepository[T]
+	WithoutTieBreaker() *GenericRepository[T]
+	SnapshotPaginate(req PageTokenRequest) (*PageTokenResponse[T], error)
+	Filter(field, op string, value any) *GenericRepository[T]
+	SortBy(field string, desc bool) *GenericRepository[T]
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) *GenericRepository[T]
+	DeleteCascade(id any, plan CascadePlan) error
+	Restore(id any) error
+	RestoreCascade(id any) error
+	Trash() *GenericRepository[T]
+	ListTrashed() (*[]T, error)
+	PurgeOlderThan(age time.Duration) (int64, error)
+	CopyTo(target *gorm.DB, filters map[string]any, opts CopyOptions) (*CopyResult, error)
+	ReadOptimized(opts ReadOptimizedOptions) *GenericRepository[T]
+	Final() *GenericRepository[T]
+	WithPlugins(plugins ...gorm.Plugin) *GenericRepository[T]
+	WithCircuitBreaker(cb *CircuitBreaker) *GenericRepository[T]
+	WithMaxConcurrent(n int, queueTimeout time.Duration) *GenericRepository[T]
+	WithLoadShedding(opts LoadShedding) *GenericRepository[T]
+	WithOperationLog(sink OperationSink, values bool) *GenericRepository[T]
+	Anonymize(id any, policy ErasurePolicy) error
+	ExportSubject(subjectKey any, associations ...string) ([]byte, error)
+	WithReadReplica(replica *gorm.DB) *GenericRepository[T]
+	ConsistencyToken() ConsistencyToken
+	WithConsistencyToken(token ConsistencyToken) *GenericRepository[T]
+	WithDB(db *gorm.DB) *GenericRepository[T]
+	Select(query interface{}, args ...interface{}) *GenericRepository[T]
+	Group(name string) *GenericRepository[T]
+	Having(query interface{}, args ...interface{}) *GenericRepository[T]
+	Or(query interface{}, args ...interface{}) *GenericRepository[T]
+	Not(query interface{}, args ...interface{}) *GenericRepository[T]
+
+	// Finalizer methods - execute the query and return the result
+	First() (*T, error) // Returns first entity found
+	Get() (*[]T, error) // Returns slice of entities
+	One() (*T, error)   // Returns one entity or error if not exactly one found
+	// FindFirst() (*T, error) // Alias for First() for compatibility
+	Sample(n int) (*[]T, error)
+	FirstByMax(field string) (*T, error)
+	FirstByMin(field string) (*T, error)
+	LatestPerGroup(groupField, orderField string) (*[]T, error)
+	Pivot(column string, rows ...string) *PivotQuery[T]
+	FanOut(dbs ...*gorm.DB) (*FanOutResult[T], error)
+	ScanInto(dest interface{}) error // Scans the chain result into a non-entity struct
+
+	// Projection methods - return repository configured to use projection
+	// ProjectTo(dtoInterface interface{}) *GenericRepository[T]
+	ProjectToPartial(dtoInterface interface{}) *GenericRepository[T] // Returns entity with only projection fields filled
+	ProjectToDTO(dtoInterface interface{}) *GenericRepository[T]     // Returns only DTO, not complete entity
+	LoadedFields() FieldMask
+	WithTagPriority(tags ...string) *GenericRepository[T]
+	SelectFields(fields ...string) *GenericRepository[T]
+	GetMaps() ([]map[string]interface{}, error)
+	ProjectFromFieldSet(fields []string) *GenericRepository[T]
+	ReadWithFieldMask(mask []string) *GenericRepository[T]
+
+	// Conversion methods for real DTO - works with repository current result
+	Project() (interface{}, error)      // Converts currentResult to real DTO using configured projection
+	ProjectSlice() (interface{}, error) // Converts currentSlice to slice of real DTOs using configured projection
+
+	// Static conversion methods (for compatibility)
+	ProjectEntity(entity *T, dtoInterface interface{}) (interface{}, error)
+	ProjectEntitySlice(entities *[]T, dtoInterface interface{}) (interface{}, error)
+
+	// Helper methods to check state
+	HasError() bool
+	Error() error
+	Result() (*T, error)    // Returns currentResult and lastError
+	Results() (*[]T, error) // Returns currentSlice and lastError
+	Execute() error         // Finalizes operation and returns only error
+	Exec() *Executor[T]
+	TryFirst() Result[T]
+	TryGet() Result[[]T]
+}
+type GenericRepository[T any] struct {
+	db             *gorm.DB
+	projection     interface{} // Stores DTO type for projection
+	projectionMode string      // "full", "partial", "dto"
+	tagPriority    []string    // Struct tags mapping DTO fields to columns, in order
+	loadedFields   FieldMask   // Fields loaded by a partial projection
+	spec           QuerySpec   // Serializable part of the chain
+	currentResult  *T          // Stores current result for chaining
+	currentSlice   *[]T        // Stores slice of results for chaining
+	lastError      error       // Stores last error that occurred
+
+	eventHandlers []EventHandler
+	useOutbox     bool
+	pending       *pendingWork[T] // Work waiting for the surrounding transaction to commit
+	operation     OperationKind   // Kind of the operation being run
+
+	afterCommitHooks   []CommitHook[T]
+	afterRollbackHooks []CommitHook[T]
+
+	maxRows      int
+	maxOffset    int
+	quotaLimit   int64
+	defaultLimit int
+	defaultOrder string
+	noTieBreaker bool
+	strictWrites bool
+	stateMachine *StateMachine
+	middleware   []Middleware
+	owner        *schema.Field // Owner column of WithOwnership
+	columnPolicy ColumnPolicy
+	idGenerator  IDGenerator
+	idAllocator  IDAllocator
+
+	loadStrategies map[string]LoadStrategy
+	attachOwner    *polymorphicRef
+
+	recursiveRelations []*schema.Relationship
+
+	replica    *gorm.DB
+	writeToken ConsistencyToken
+	readToken  ConsistencyToken
+
+	clock              Clock
+	preserveTimestamps bool
+	location           *time.Location
+
+	searchCollation string
+	unaccent        bool
+
+	blobStore   BlobStore
+	fileStorage FileStorage
+	locale      string
+
+	readOptimized bool
+	asyncInsert   bool
+
+	cache    Cache
+	cacheTTL time.Duration
+	missTTL  time.Duration
+	flights  *flightGroup // Collapses concurrent cache misses of an id
+}
+
+func New[T any](db *gorm.DB) *GenericRepository[T] {
+	if db == nil {
+		panic("database not initialized")
+	}
+	return &GenericRepository[T]{
+		db:            db,
+		currentResult: nil,
+		currentSlice:  nil,
+		lastError:     nil,
+	}
+}
+
+// clone returns a repository with the same configuration on db. The results,
+// error and pending work of r are not carried over.
+func (r *GenericRepository[T]) clone(db *gorm.DB) *GenericRepository[T] {
+	c := *r
+	c.db = db
+	c.currentResult = nil
+	c.currentSlice = nil
+	c.lastError = nil
+	c.pending = nil
+	c.writeToken = ""
+
+	// Options added to the clone must not end up in r
+	c.eventHandlers = slices.Clip(c.eventHandlers)
+	c.afterCommitHooks = slices.Clip(c.afterCommitHooks)
+	c.afterRollbackHooks = slices.Clip(c.afterRollbackHooks)
+	c.middleware = slices.Clip(c.middleware)
+	c.loadStrategies = maps.Clone(c.loadStrategies)
+	return &c
+}
@@ -0,0 +1,121 @@
+package gormrepo
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type tenantItem struct {
+	ID       int64
+	TenantID int64 `quota:"tenant"`
+	Name     string
+	Price    int64
+}
+
+type tenantItemName struct {
+	Name string
+}
+
+// configured holds a repository set up with read and write options, and
+// what its hooks observed.
+type configured struct {
+	reads, writes *GenericRepository[tenantItem]
+	operations    []OperationKind
+	committed     int
+}
+
+func configure(db *gorm.DB) *configured {
+	c := &configured{}
+	c.reads = New[tenantItem](db).
+		ProjectToPartial(&tenantItemName{}).
+		WithDefaultOrder("name").
+		WithDefaultLimit(5)
+	c.writes = New[tenantItem](db).
+		EnforceQuota(10).
+		Use(func(next Handler) Handler {
+			return func(op Operation) error {
+				c.operations = append(c.operations, op.Kind)
+				return next(op)
+			}
+		}).
+		AfterCommit(func(ctx context.Context, changes []Change[tenantItem]) error {
+			c.committed += len(changes)
+			return nil
+		})
+	return c
+}
+
+// checkReads runs a read on r, derived from c.reads, and checks the
+// projection, default order and default limit applied.
+func checkReads(t *testing.T, r *GenericRepository[tenantItem], fake *fakeDB) {
+	t.Helper()
+	if _, err := r.Get(); err != nil {
+		t.Fatal(err)
+	}
+	got := fake.ranLike(" FROM ")
+	want := `SELECT "name","id" FROM "tenant_items" ORDER BY name LIMIT ? [5]`
+	if len(got) == 0 || !strings.HasPrefix(got[len(got)-1], want) {
+		t.Errorf("ran %q, want %s", got, want)
+	}
+}
+
+// checkWrite creates an entity with r, derived from c.writes, and checks the
+// quota and middleware applied.
+func checkWrite(t *testing.T, c *configured, r *GenericRepository[tenantItem], fake *fakeDB) {
+	t.Helper()
+	if err := r.Create(&tenantItem{TenantID: 7, Name: "lamp"}).Error(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.ranLike("counters")) == 0 {
+		t.Errorf("quota not enforced, ran %q", fake.ran())
+	}
+	if !slices.Contains(c.operations, OpCreate) {
+		t.Errorf("middleware saw %v", c.operations)
+	}
+}
+
+func TestWithDBKeepsConfiguration(t *testing.T) {
+	source, sourceFake := newTestDB(t, "postgres", "16.2")
+	db, fake := newTestDB(t, "postgres", "16.2")
+	c := configure(source)
+
+	checkReads(t, c.reads.WithDB(db), fake)
+	checkWrite(t, c, c.writes.WithDB(db), fake)
+	if c.committed != 1 {
+		t.Errorf("after commit hooks got %d changes, want 1", c.committed)
+	}
+	if got := sourceFake.ranLike("tenant_items"); len(got) != 0 {
+		t.Errorf("ran %q on the source database", got)
+	}
+}
+
+func TestTransactionKeepsConfiguration(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	c := configure(db)
+
+	err := c.reads.Transaction(func(tx *GenericRepository[tenantItem]) error {
+		checkReads(t, tx, fake)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.writes.Transaction(func(tx *GenericRepository[tenantItem]) error {
+		checkWrite(t, c, tx, fake)
+		if c.committed != 0 {
+			t.Errorf("after commit hooks ran inside the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.committed != 1 {
+		t.Errorf("after commit hooks got %d changes, want 1", c.committed)
+	}
+}
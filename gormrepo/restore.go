@@ -0,0 +1,152 @@
+package gormrepo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrNotSoftDeletable = errors.New("entity has no soft delete field")
+
+// deletionGroupColumn is the optional column DeleteCascade stamps on the
+// rows it soft deletes together, so RestoreCascade can bring them back.
+const deletionGroupColumn = "deletion_group"
+
+// Restore undeletes the soft-deleted entity with the given id.
+func (r *GenericRepository[T]) Restore(id any) error {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return gorm.ErrPrimaryKeyRequired
+	}
+	if softDeleteField(s) == nil {
+		return ErrNotSoftDeletable
+	}
+	pk := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName}, Value: id}
+
+	return r.write(OpUpdate, nil, func(db *gorm.DB) error {
+		return restoreRows(db, s, pk)
+	})
+}
+
+// RestoreCascade undeletes the soft-deleted entity with the given id and
+// the associated rows DeleteCascade soft deleted with it. Rows are matched
+// by a deletion_group column, which the entity and the associated tables
+// need. Many-to-many join rows, which are deleted for good, aren't restored.
+func (r *GenericRepository[T]) RestoreCascade(id any) error {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return gorm.ErrPrimaryKeyRequired
+	}
+	if softDeleteField(s) == nil {
+		return ErrNotSoftDeletable
+	}
+	pk := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName}, Value: id}
+
+	return r.write(OpUpdate, nil, func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			var groups []string
+			if s.LookUpField(deletionGroupColumn) != nil {
+				err := tx.Unscoped().Model(new(T)).Where(pk).Where(clause.Neq{Column: clause.Column{Name: deletionGroupColumn}, Value: nil}).
+					Pluck(deletionGroupColumn, &groups).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := restoreRows(tx, s, pk); err != nil {
+				return err
+			}
+			if len(groups) == 0 {
+				return nil
+			}
+
+			group := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: deletionGroupColumn}, Value: groups[0]}
+			fresh := tx.Session(&gorm.Session{NewDB: true})
+			for _, related := range relatedSchemas(s) {
+				if softDeleteField(related) == nil || related.LookUpField(deletionGroupColumn) == nil {
+					continue
+				}
+				if err := restoreRows(fresh, related, group); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// restoreRows clears the soft delete field of the deleted rows of s
+// matching condition.
+func restoreRows(db *gorm.DB, s *schema.Schema, condition clause.Expression) error {
+	updates := map[string]any{softDeleteField(s).DBName: nil}
+	if s.LookUpField(deletionGroupColumn) != nil {
+		updates[deletionGroupColumn] = nil
+	}
+	res := db.Unscoped().Model(reflect.New(s.ModelType).Interface()).
+		Where(condition).
+		Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: softDeleteField(s).DBName}, Value: nil}).
+		Updates(updates)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+// stampDeletionGroup records group on the soft-deleted rows of s matching
+// condition, when s has a deletion_group column.
+func stampDeletionGroup(db *gorm.DB, s *schema.Schema, condition clause.Expression, group string) error {
+	if softDeleteField(s) == nil || s.LookUpField(deletionGroupColumn) == nil {
+		return nil
+	}
+	return db.Unscoped().Model(reflect.New(s.ModelType).Interface()).
+		Where(condition).
+		UpdateColumn(deletionGroupColumn, group).Error
+}
+
+func newDeletionGroup() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func softDeleteField(s *schema.Schema) *schema.Field {
+	for _, field := range s.Fields {
+		if field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) && field.DBName != "" {
+			return field
+		}
+	}
+	return nil
+}
+
+// relatedSchemas returns the schemas reachable from s through has-one and
+// has-many associations.
+func relatedSchemas(s *schema.Schema) []*schema.Schema {
+	seen := map[*schema.Schema]bool{s: true}
+	queue := []*schema.Schema{s}
+	var related []*schema.Schema
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, rel := range current.Relationships.Relations {
+			if (rel.Type != schema.HasOne && rel.Type != schema.HasMany) || seen[rel.FieldSchema] {
+				continue
+			}
+			seen[rel.FieldSchema] = true
+			related = append(related, rel.FieldSchema)
+			queue = append(queue, rel.FieldSchema)
+		}
+	}
+	return related
+}
@@ -0,0 +1,77 @@
+package gormrepo
+
+// Result holds either a value or the error that prevented producing it.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// Map applies fn to the value of a successful result.
+func (r Result[T]) Map(fn func(T) T) Result[T] {
+	if r.err != nil {
+		return r
+	}
+	return Ok(fn(r.value))
+}
+
+// OrElse returns the value, or fallback when the result is an error.
+func (r Result[T]) OrElse(fallback T) T {
+	if r.err != nil {
+		return fallback
+	}
+	return r.value
+}
+
+// MapResult is Map for functions changing the value's type.
+func MapResult[T, U any](r Result[T], fn func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return Ok(fn(r.value))
+}
+
+// TryFirst is First returning a Result. Errors recorded earlier in the chain
+// are returned too.
+func (r *GenericRepository[T]) TryFirst() Result[T] {
+	if r.lastError != nil {
+		return Err[T](r.lastError)
+	}
+	entity, err := r.First()
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(*entity)
+}
+
+// TryGet is Get returning a Result. Errors recorded earlier in the chain are
+// returned too.
+func (r *GenericRepository[T]) TryGet() Result[[]T] {
+	if r.lastError != nil {
+		return Err[[]T](r.lastError)
+	}
+	entities, err := r.Get()
+	if err != nil {
+		return Err[[]T](err)
+	}
+	return Ok(*entities)
+}
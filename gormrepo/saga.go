@@ -0,0 +1,82 @@
+package gormrepo
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type SagaStep struct {
+	Name       string
+	Action     func(ctx context.Context) error
+	Compensate func(ctx context.Context) error // Optional; nil means nothing to undo
+}
+
+// Saga runs steps in order; when one fails, the steps that already ran are
+// compensated in reverse order.
+type Saga struct {
+	steps []SagaStep
+}
+
+type SagaError struct {
+	Step               string
+	Err                error
+	CompensationErrors map[string]error // Keyed by step name
+}
+
+func (e *SagaError) Error() string {
+	msg := fmt.Sprintf("saga step %s failed: %v", e.Step, e.Err)
+	if len(e.CompensationErrors) == 0 {
+		return msg
+	}
+
+	var failed []string
+	for _, step := range slices.Sorted(maps.Keys(e.CompensationErrors)) {
+		failed = append(failed, fmt.Sprintf("%s: %v", step, e.CompensationErrors[step]))
+	}
+	return msg + "; compensation failed for " + strings.Join(failed, ", ")
+}
+
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+func NewSaga() *Saga {
+	return &Saga{}
+}
+
+func (s *Saga) Step(name string, action, compensate func(ctx context.Context) error) *Saga {
+	s.steps = append(s.steps, SagaStep{Name: name, Action: action, Compensate: compensate})
+	return s
+}
+
+func (s *Saga) Run(ctx context.Context) error {
+	for i, step := range s.steps {
+		if err := step.Action(ctx); err != nil {
+			return s.compensate(ctx, i, &SagaError{Step: step.Name, Err: err})
+		}
+	}
+	return nil
+}
+
+func (s *Saga) compensate(ctx context.Context, failed int, sagaErr *SagaError) error {
+	// Compensation must still run when the failure was a cancelled context
+	ctx = context.WithoutCancel(ctx)
+
+	for i := failed - 1; i >= 0; i-- {
+		step := s.steps[i]
+		if step.Compensate == nil {
+			continue
+		}
+		if err := step.Compensate(ctx); err != nil {
+			if sagaErr.CompensationErrors == nil {
+				sagaErr.CompensationErrors = make(map[string]error)
+			}
+			sagaErr.CompensationErrors[step.Name] = err
+		}
+	}
+
+	return sagaErr
+}
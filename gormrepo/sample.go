@@ -0,0 +1,76 @@
+package gormrepo
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SampleWarnRows is the estimated table size above which Sample logs a
+// warning, as ordering by a random value sorts every matching row.
+var SampleWarnRows int64 = 1_000_000
+
+// Sample returns up to n random entities matching the chain. On Postgres,
+// tables estimated above SampleWarnRows are read through TABLESAMPLE, which
+// may return fewer than n rows under selective filters.
+func (r *GenericRepository[T]) Sample(n int) (*[]T, error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("sample size must be positive")
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.db
+	if estimate := r.estimatedRows(s.Table); estimate > SampleWarnRows {
+		if db.Dialector.Name() == "postgres" {
+			// Oversample so filters still leave n rows in most cases
+			percent := min(100, float64(n)*1000/float64(estimate))
+			db = db.Table("? TABLESAMPLE BERNOULLI (?)", clause.Table{Name: s.Table}, percent)
+		} else {
+			db.Logger.Warn(r.context(), "sampling %d rows of %s sorts all of its ~%d rows", n, s.Table, estimate)
+		}
+	}
+
+	var entities []T
+	err = r.run(OpQuery, nil, func() error {
+		return r.redacted(r.readDB(db)).Order(clause.OrderBy{Expression: randomOrder(db)}).Limit(n).Find(&entities).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.afterLoad(pointersTo(entities)...)
+	return &entities, nil
+}
+
+func randomOrder(db *gorm.DB) clause.Expr {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return clause.Expr{SQL: "RAND()"}
+	case "sqlserver":
+		return clause.Expr{SQL: "NEWID()"}
+	default:
+		return clause.Expr{SQL: "RANDOM()"}
+	}
+}
+
+// estimatedRows reads the planner's row estimate for table, or 0 when the
+// dialect has none.
+func (r *GenericRepository[T]) estimatedRows(table string) int64 {
+	db := r.db.Session(&gorm.Session{NewDB: true})
+
+	var estimate int64
+	switch db.Dialector.Name() {
+	case "postgres":
+		db.Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", table).Scan(&estimate)
+	case "mysql":
+		db.Raw("SELECT table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table).Scan(&estimate)
+	}
+	return estimate
+}
@@ -0,0 +1,76 @@
+package gormrepo
+
+import (
+	"fmt"
+	"regexp"
+
+	"gorm.io/gorm/clause"
+)
+
+var collationName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// WithSearchCollation makes WhereIEquals and WhereILike compare under
+// collation, e.g. utf8mb4_0900_ai_ci on MySQL or an ICU collation on Postgres
+// for accent-insensitive matches.
+func (r *GenericRepository[T]) WithSearchCollation(collation string) *GenericRepository[T] {
+	if !collationName.MatchString(collation) {
+		r.lastError = fmt.Errorf("invalid collation %q", collation)
+		return r
+	}
+	r.searchCollation = collation
+	return r
+}
+
+// WithUnaccent makes WhereIEquals and WhereILike ignore accents using the
+// unaccent extension, which must be installed. Postgres only.
+func (r *GenericRepository[T]) WithUnaccent() *GenericRepository[T] {
+	if name := r.db.Dialector.Name(); name != "postgres" {
+		r.lastError = fmt.Errorf("unaccent is not supported on %s", name)
+		return r
+	}
+	r.unaccent = true
+	return r
+}
+
+// WhereIEquals matches rows where column equals value ignoring case.
+func (r *GenericRepository[T]) WhereIEquals(column string, value string) *GenericRepository[T] {
+	col, val := r.searchOperands(column)
+	if r.searchCollation != "" {
+		r.db = r.db.Where(clause.Expr{SQL: col + " = " + val, Vars: []interface{}{clause.Column{Name: column}, value}})
+		return r
+	}
+	r.db = r.db.Where(clause.Expr{SQL: "LOWER(" + col + ") = LOWER(" + val + ")", Vars: []interface{}{clause.Column{Name: column}, value}})
+	return r
+}
+
+// WhereILike matches rows where column matches the LIKE pattern ignoring
+// case: ILIKE where the database has it and LOWER() on both sides elsewhere.
+func (r *GenericRepository[T]) WhereILike(column string, pattern string) *GenericRepository[T] {
+	col, val := r.searchOperands(column)
+	sql := "LOWER(" + col + ") LIKE LOWER(" + val + ")"
+	if DetectCapabilities(r.db).ILike {
+		sql = col + " ILIKE " + val
+	}
+	r.db = r.db.Where(clause.Expr{SQL: sql, Vars: []interface{}{clause.Column{Name: column}, pattern}})
+	return r
+}
+
+// searchOperands returns the column and value placeholders of a search,
+// wrapped for unaccent and the search collation.
+func (r *GenericRepository[T]) searchOperands(column string) (string, string) {
+	col, val := "?", "?"
+	if r.unaccent {
+		col, val = "unaccent("+col+")", "unaccent("+val+")"
+	}
+	if r.searchCollation != "" {
+		collation := r.searchCollation
+		switch r.db.Dialector.Name() {
+		case "postgres":
+			collation = `"` + collation + `"`
+		case "mysql":
+			collation = "`" + collation + "`"
+		}
+		col += " COLLATE " + collation
+	}
+	return col, val
+}
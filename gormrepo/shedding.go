@@ -0,0 +1,73 @@
+package gormrepo
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOverloaded = errors.New("database overloaded")
+
+// LoadShedding sets when reads are rejected because callers queue for pool
+// connections. Thresholds apply to the waits of each interval; zero ones
+// are not checked.
+type LoadShedding struct {
+	MaxWaitCount    int64
+	MaxWaitDuration time.Duration
+	Interval        time.Duration // Defaults to one second
+}
+
+type loadShedder struct {
+	LoadShedding
+	pool *sql.DB
+
+	mu         sync.Mutex
+	sampledAt  time.Time
+	last       sql.DBStats
+	overloaded bool
+}
+
+// WithLoadShedding makes reads fail with ErrOverloaded while the connection
+// pool statistics exceed the thresholds of opts, so that writes and the
+// queries already running get the connections. Writes are never rejected.
+func (r *GenericRepository[T]) WithLoadShedding(opts LoadShedding) *GenericRepository[T] {
+	pool, err := r.db.DB()
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	shedder := &loadShedder{LoadShedding: opts, pool: pool, sampledAt: time.Now(), last: pool.Stats()}
+
+	return r.Use(func(next Handler) Handler {
+		return func(op Operation) error {
+			if !op.Kind.IsWrite() && shedder.overloadedNow() {
+				return ErrOverloaded
+			}
+			return next(op)
+		}
+	})
+}
+
+// overloadedNow compares the waits since the previous sample with the
+// thresholds, sampling at most once per interval.
+func (s *loadShedder) overloadedNow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.sampledAt) < s.Interval {
+		return s.overloaded
+	}
+
+	stats := s.pool.Stats()
+	waits := stats.WaitCount - s.last.WaitCount
+	waited := stats.WaitDuration - s.last.WaitDuration
+	s.overloaded = (s.MaxWaitCount > 0 && waits > s.MaxWaitCount) ||
+		(s.MaxWaitDuration > 0 && waited > s.MaxWaitDuration)
+	s.last = stats
+	s.sampledAt = time.Now()
+	return s.overloaded
+}
@@ -0,0 +1,52 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadSheddingRejectsReadsWhileCallersWait(t *testing.T) {
+	db, _ := newTestDB(t, "postgres", "16.2")
+	pool, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.SetMaxOpenConns(1)
+	r := New[testItem](db).WithLoadShedding(LoadShedding{MaxWaitDuration: time.Millisecond, Interval: time.Millisecond})
+
+	// A caller waits for the only connection
+	ctx := context.Background()
+	held, err := pool.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waited := make(chan error)
+	go func() {
+		conn, err := pool.Conn(ctx)
+		if err == nil {
+			err = conn.Close()
+		}
+		waited <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	held.Close()
+	if err := <-waited; err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := r.clone(db).Get(); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("got %v, want ErrOverloaded", err)
+	}
+	if err := r.clone(db).Create(&testItem{Name: "lamp"}).Error(); err != nil {
+		t.Errorf("write rejected: %v", err)
+	}
+
+	// No one waited since
+	time.Sleep(5 * time.Millisecond)
+	if _, err := r.clone(db).Get(); err != nil {
+		t.Error(err)
+	}
+}
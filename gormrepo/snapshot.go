@@ -0,0 +1,107 @@
+package gormrepo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type snapshotToken struct {
+	Offset   int             `json:"o"`
+	Boundary json.RawMessage `json:"b"`
+}
+
+// SnapshotPaginate pages like ListPage through the rows that existed when
+// the first page was read: the first page captures the largest primary key
+// and its token carries it, so rows inserted meanwhile don't shift the
+// following pages. It needs an increasing primary key.
+func (r *GenericRepository[T]) SnapshotPaginate(req PageTokenRequest) (*PageTokenResponse[T], error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if req.PageSize < 0 {
+		return nil, fmt.Errorf("page size cannot be negative")
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, gorm.ErrPrimaryKeyRequired
+	}
+	pk := clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName}
+
+	var token snapshotToken
+	if req.PageToken != "" {
+		payload, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+		if err == nil {
+			err = json.Unmarshal(payload, &token)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+		}
+		if token.Offset < 0 {
+			return nil, fmt.Errorf("%w: negative offset", ErrInvalidPageToken)
+		}
+	}
+	if err := r.checkOffset(token.Offset); err != nil {
+		return nil, err
+	}
+
+	boundary := reflect.New(s.PrioritizedPrimaryField.FieldType)
+	if token.Boundary == nil {
+		max := reflect.New(reflect.PointerTo(boundary.Type().Elem()))
+		err := r.run(OpQuery, nil, func() error {
+			return r.readDB(r.owned(r.db.Session(&gorm.Session{NewDB: true})).Model(new(T))).
+				Select("MAX(?)", pk).
+				Row().Scan(max.Interface())
+		})
+		if err != nil {
+			return nil, err
+		}
+		if max.Elem().IsNil() {
+			empty := []T{}
+			r.currentSlice = &empty
+			return &PageTokenResponse[T]{Items: &empty}, nil
+		}
+		boundary = max.Elem()
+		if token.Boundary, err = json.Marshal(boundary.Interface()); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(token.Boundary, boundary.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	// Fetch one extra row to know whether another page exists
+	var entities []T
+	err = r.run(OpQuery, nil, func() error {
+		query := r.tieBroken(r.orderedQuery(r.db)).Where(clause.Lte{Column: pk, Value: boundary.Elem().Interface()})
+		return unorderedPage(r.redacted(r.readDB(query)).Offset(token.Offset).Limit(pageSize + 1)).Find(&entities).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nextPageToken := ""
+	if len(entities) > pageSize {
+		entities = entities[:pageSize]
+		payload, _ := json.Marshal(snapshotToken{Offset: token.Offset + pageSize, Boundary: token.Boundary})
+		nextPageToken = base64.RawURLEncoding.EncodeToString(payload)
+	}
+	r.afterLoad(pointersTo(entities)...)
+
+	r.currentSlice = &entities
+	return &PageTokenResponse[T]{Items: &entities, NextPageToken: nextPageToken}, nil
+}
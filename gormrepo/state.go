@@ -0,0 +1,140 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/spirandev/go-gormrepo/gormrepo/internal/pkhelper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// StateMachine lists, for each state of a status column, the states it may move to.
+type StateMachine struct {
+	Column      string
+	Transitions map[string][]string
+}
+
+type TransitionError struct {
+	Column string
+	From   string
+	To     string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid transition of %s from %q to %q", e.Column, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// WithStateMachine makes Update and UpdateFields check status changes against
+// the allowed transitions. The stored state is read with a row lock in the
+// same transaction as the write.
+func (r *GenericRepository[T]) WithStateMachine(column string, transitions map[string][]string) *GenericRepository[T] {
+	r.stateMachine = &StateMachine{Column: column, Transitions: transitions}
+	return r
+}
+
+func (m *StateMachine) allows(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range m.Transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// entityState returns the state held by entity, if the state machine applies.
+func (r *GenericRepository[T]) entityState(entity *T) (string, bool) {
+	if r.stateMachine == nil {
+		return "", false
+	}
+	s, err := parseSchema(r.db, entity)
+	if err != nil {
+		return "", false
+	}
+	field := s.LookUpField(r.stateMachine.Column)
+	if field == nil {
+		return "", false
+	}
+	value, _ := field.ValueOf(r.context(), reflect.ValueOf(entity).Elem())
+	return stateString(value), true
+}
+
+// fieldsState returns the state set by a partial update, if any.
+func (r *GenericRepository[T]) fieldsState(fields map[string]interface{}) (string, bool) {
+	if r.stateMachine == nil {
+		return "", false
+	}
+	if value, ok := fields[r.stateMachine.Column]; ok {
+		return stateString(value), true
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return "", false
+	}
+	if field := s.LookUpField(r.stateMachine.Column); field != nil {
+		for _, key := range []string{field.Name, field.DBName} {
+			if value, ok := fields[key]; ok {
+				return stateString(value), true
+			}
+		}
+	}
+	return "", false
+}
+
+// checkTransition locks the row identified by pkValue and verifies its stored
+// state may move to next. Rows that don't exist yet have no transition to check.
+func (r *GenericRepository[T]) checkTransition(tx *gorm.DB, pkValue any, next string) error {
+	var states []string
+	err := forUpdate(tx.Model(new(T)), false).
+		Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: r.primaryKeyColumn()}, Value: pkValue}).
+		Limit(1).
+		Pluck(r.stateMachine.Column, &states).Error
+	if err != nil || len(states) == 0 {
+		return err
+	}
+	if !r.stateMachine.allows(states[0], next) {
+		return &TransitionError{Column: r.stateMachine.Column, From: states[0], To: next}
+	}
+	return nil
+}
+
+// withTransitionCheck wraps an update of entity so it runs after the state
+// transition check, in one transaction.
+func (r *GenericRepository[T]) withTransitionCheck(entity *T, next string, op func(db *gorm.DB) error) func(db *gorm.DB) error {
+	_, pkValue, err := pkhelper.GetPrimaryKey(entity)
+	if err != nil || reflect.ValueOf(pkValue).IsZero() {
+		return op
+	}
+	return func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := r.checkTransition(tx, pkValue, next); err != nil {
+				return err
+			}
+			return op(tx)
+		})
+	}
+}
+
+func stateString(value any) string {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
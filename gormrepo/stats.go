@@ -0,0 +1,157 @@
+package gormrepo
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	statsStartKey    = "gormrepo:stats_start"
+	statsSampleLimit = 1024
+)
+
+var (
+	fingerprintStrings      = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumbers      = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	fingerprintPlaceholders = regexp.MustCompile(`\$\d+|@p\d+|:\d+`)
+	fingerprintLists        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	fingerprintSpaces       = regexp.MustCompile(`\s+`)
+)
+
+type QueryStats struct {
+	Fingerprint   string
+	Calls         int64
+	TotalDuration time.Duration
+	MeanDuration  time.Duration
+	P95Duration   time.Duration
+	Rows          int64
+}
+
+type queryStats struct {
+	calls   int64
+	total   time.Duration
+	rows    int64
+	samples []time.Duration // Ring buffer of the latest durations, used for percentiles
+	next    int
+}
+
+// StatsCollector aggregates execution statistics per normalized statement.
+// It is a gorm plugin: enable it with db.Use(collector).
+type StatsCollector struct {
+	mu    sync.Mutex
+	stats map[string]*queryStats
+}
+
+func NewStatsCollector() *StatsCollector {
+	return &StatsCollector{stats: make(map[string]*queryStats)}
+}
+
+func (c *StatsCollector) Name() string {
+	return "gormrepo:stats"
+}
+
+func (c *StatsCollector) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("gormrepo:stats_before_create", c.before),
+		cb.Create().After("gorm:create").Register("gormrepo:stats_after_create", c.after),
+		cb.Query().Before("gorm:query").Register("gormrepo:stats_before_query", c.before),
+		cb.Query().After("gorm:query").Register("gormrepo:stats_after_query", c.after),
+		cb.Update().Before("gorm:update").Register("gormrepo:stats_before_update", c.before),
+		cb.Update().After("gorm:update").Register("gormrepo:stats_after_update", c.after),
+		cb.Delete().Before("gorm:delete").Register("gormrepo:stats_before_delete", c.before),
+		cb.Delete().After("gorm:delete").Register("gormrepo:stats_after_delete", c.after),
+		cb.Row().Before("gorm:row").Register("gormrepo:stats_before_row", c.before),
+		cb.Row().After("gorm:row").Register("gormrepo:stats_after_row", c.after),
+		cb.Raw().Before("gorm:raw").Register("gormrepo:stats_before_raw", c.before),
+		cb.Raw().After("gorm:raw").Register("gormrepo:stats_after_raw", c.after),
+	)
+}
+
+func (c *StatsCollector) before(db *gorm.DB) {
+	db.InstanceSet(statsStartKey, time.Now())
+}
+
+func (c *StatsCollector) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(statsStartKey)
+	if !ok || db.DryRun || db.Statement.SQL.Len() == 0 {
+		return
+	}
+	elapsed := time.Since(value.(time.Time))
+	c.Record(db.Statement.SQL.String(), elapsed, db.Statement.RowsAffected)
+}
+
+// Record adds one execution of sql to the statistics.
+func (c *StatsCollector) Record(sql string, elapsed time.Duration, rows int64) {
+	fingerprint := Fingerprint(sql)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[fingerprint]
+	if !ok {
+		s = &queryStats{}
+		c.stats[fingerprint] = s
+	}
+
+	s.calls++
+	s.total += elapsed
+	if rows > 0 {
+		s.rows += rows
+	}
+	if len(s.samples) < statsSampleLimit {
+		s.samples = append(s.samples, elapsed)
+	} else {
+		s.samples[s.next] = elapsed
+		s.next = (s.next + 1) % statsSampleLimit
+	}
+}
+
+// Stats returns a snapshot ordered by total time spent, most expensive first.
+func (c *StatsCollector) Stats() []QueryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make([]QueryStats, 0, len(c.stats))
+	for fingerprint, s := range c.stats {
+		samples := append([]time.Duration(nil), s.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+		snapshot = append(snapshot, QueryStats{
+			Fingerprint:   fingerprint,
+			Calls:         s.calls,
+			TotalDuration: s.total,
+			MeanDuration:  s.total / time.Duration(s.calls),
+			P95Duration:   samples[(len(samples)*95+99)/100-1],
+			Rows:          s.rows,
+		})
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].TotalDuration > snapshot[j].TotalDuration
+	})
+	return snapshot
+}
+
+func (c *StatsCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = make(map[string]*queryStats)
+}
+
+// Fingerprint normalizes a statement so that executions differing only in
+// literal values, placeholder numbering or IN list length share one entry.
+func Fingerprint(sql string) string {
+	fp := fingerprintStrings.ReplaceAllString(sql, "?")
+	fp = fingerprintPlaceholders.ReplaceAllString(fp, "?")
+	fp = fingerprintNumbers.ReplaceAllString(fp, "?")
+	fp = fingerprintLists.ReplaceAllString(fp, "(?+)")
+	fp = fingerprintSpaces.ReplaceAllString(fp, " ")
+	return strings.TrimSpace(fp)
+}
@@ -0,0 +1,165 @@
+package gormrepo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrTenantMismatch = errors.New("tenant copy doesn't match its source")
+
+// Tenant move states, recorded in the source database. Routing should pause
+// writes of a moving tenant and send a moved tenant to its new database.
+const (
+	TenantMoving = "moving"
+	TenantMoved  = "moved"
+)
+
+type TenantMove struct {
+	Tenant    string `gorm:"primaryKey;size:191"`
+	State     string
+	UpdatedAt time.Time
+}
+
+func (TenantMove) TableName() string {
+	return "tenant_moves"
+}
+
+type TenantTable struct {
+	Table    string
+	Rows     int64
+	Checksum string
+}
+
+// MoveTenant copies the rows of tenant from one database to another for
+// every registered model with a field tagged quota:"tenant", in
+// registration order, so parents should be registered before children.
+// The copy is verified by row count and checksum before the tenant is
+// flagged TenantMoved in from; rows already copied are overwritten, so a
+// failed move can be run again. The source rows are left for the caller to
+// delete once routing has switched over.
+func MoveTenant(tenant any, from, to *gorm.DB) ([]TenantTable, error) {
+	type tenantModel struct {
+		schema *schema.Schema
+		where  clause.Eq
+	}
+	var models []tenantModel
+	for _, model := range registeredModels() {
+		s, err := parseSchema(from, model)
+		if err != nil {
+			return nil, err
+		}
+		field := tenantField(s)
+		if field == nil {
+			continue
+		}
+		if s.PrioritizedPrimaryField == nil {
+			return nil, fmt.Errorf("%w: %s", gorm.ErrPrimaryKeyRequired, s.Name)
+		}
+		models = append(models, tenantModel{s, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenant}})
+	}
+
+	if err := from.AutoMigrate(&TenantMove{}); err != nil {
+		return nil, err
+	}
+	if err := setTenantState(from, tenant, TenantMoving); err != nil {
+		return nil, err
+	}
+
+	tables, err := func() ([]TenantTable, error) {
+		for _, m := range models {
+			if err := copyTenantRows(from, to, m.schema, m.where); err != nil {
+				return nil, fmt.Errorf("copying %s: %w", m.schema.Table, err)
+			}
+		}
+
+		tables := make([]TenantTable, 0, len(models))
+		for _, m := range models {
+			source, err := tenantChecksum(from, m.schema, m.where)
+			if err != nil {
+				return nil, err
+			}
+			target, err := tenantChecksum(to, m.schema, m.where)
+			if err != nil {
+				return nil, err
+			}
+			if source != target {
+				return nil, fmt.Errorf("%w: %s has %d rows (%s) in the source and %d (%s) in the target",
+					ErrTenantMismatch, m.schema.Table, source.Rows, source.Checksum, target.Rows, target.Checksum)
+			}
+			tables = append(tables, source)
+		}
+		return tables, nil
+	}()
+	if err != nil {
+		// The tenant stays on the source database
+		return nil, errors.Join(err, from.Where("tenant = ?", fmt.Sprint(tenant)).Delete(&TenantMove{}).Error)
+	}
+	return tables, setTenantState(from, tenant, TenantMoved)
+}
+
+// TenantState returns the move state of tenant in db, or "" when it hasn't
+// been moved.
+func TenantState(db *gorm.DB, tenant any) (string, error) {
+	var states []string
+	err := db.Model(&TenantMove{}).Where("tenant = ?", fmt.Sprint(tenant)).Pluck("state", &states).Error
+	if err != nil || len(states) == 0 {
+		return "", err
+	}
+	return states[0], nil
+}
+
+func setTenantState(db *gorm.DB, tenant any, state string) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&TenantMove{Tenant: fmt.Sprint(tenant), State: state}).Error
+}
+
+// copyTenantRows upserts the rows of s matching where, soft-deleted ones
+// included, from one database into the other.
+func copyTenantRows(from, to *gorm.DB, s *schema.Schema, where clause.Expression) error {
+	batch := reflect.New(reflect.SliceOf(s.ModelType))
+	target := to.Session(&gorm.Session{}).Unscoped().Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Session(&gorm.Session{})
+	return from.Unscoped().Model(reflect.New(s.ModelType).Interface()).Where(where).
+		FindInBatches(batch.Interface(), conflictBatchSize, func(*gorm.DB, int) error {
+			if to.Dialector.Name() == "sqlserver" && identityKey(s) {
+				return identityInsert(target, s.Table, func(tx *gorm.DB) error { return tx.Create(batch.Interface()).Error })
+			}
+			return target.Create(batch.Interface()).Error
+		}).Error
+}
+
+// tenantChecksum counts and hashes the rows of s matching where, in primary
+// key order.
+func tenantChecksum(db *gorm.DB, s *schema.Schema, where clause.Expression) (TenantTable, error) {
+	table := TenantTable{Table: s.Table}
+	hash := sha256.New()
+	batch := reflect.New(reflect.SliceOf(s.ModelType))
+	ctx := db.Statement.Context
+
+	err := db.Unscoped().Model(reflect.New(s.ModelType).Interface()).Where(where).
+		FindInBatches(batch.Interface(), conflictBatchSize, func(*gorm.DB, int) error {
+			rows := batch.Elem()
+			for i := 0; i < rows.Len(); i++ {
+				tuple := make([]any, len(s.DBNames))
+				for j, name := range s.DBNames {
+					value, _ := s.FieldsByDBName[name].ValueOf(ctx, rows.Index(i))
+					value = columnValue(value)
+					if t, ok := value.(time.Time); ok {
+						value = t.UTC()
+					}
+					tuple[j] = value
+				}
+				hash.Write([]byte(tupleKey(tuple) + "\n"))
+			}
+			table.Rows += int64(rows.Len())
+			return nil
+		}).Error
+	table.Checksum = hex.EncodeToString(hash.Sum(nil)[:16])
+	return table, err
+}
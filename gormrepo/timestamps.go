@@ -0,0 +1,170 @@
+package gormrepo
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// FrozenClock always returns t, for deterministic timestamps in tests.
+func FrozenClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// WithClock makes writes set CreatedAt/UpdatedAt from clock instead of the wall clock.
+func (r *GenericRepository[T]) WithClock(clock Clock) *GenericRepository[T] {
+	r.clock = clock
+	return r
+}
+
+// TouchTimestamps(false) keeps the CreatedAt/UpdatedAt values carried by the
+// entity instead of stamping the current time, e.g. while migrating data.
+// Batch upserts keep the value of each row, and UpdateWhere and
+// UpdateBatchFields leave UpdatedAt alone unless they set it.
+func (r *GenericRepository[T]) TouchTimestamps(enabled bool) *GenericRepository[T] {
+	r.preserveTimestamps = !enabled
+	return r
+}
+
+// untouched omits the auto-update timestamps fields doesn't set from the
+// updates of db when timestamps are preserved.
+func (r *GenericRepository[T]) untouched(db *gorm.DB, fields map[string]any) *gorm.DB {
+	if !r.preserveTimestamps {
+		return db
+	}
+	s, err := parseSchema(db, new(T))
+	if err != nil {
+		return db
+	}
+
+	omits := slices.Clone(db.Statement.Omits)
+	for _, field := range s.Fields {
+		if field.AutoUpdateTime == 0 || field.DBName == "" {
+			continue
+		}
+		_, byColumn := fields[field.DBName]
+		_, byName := fields[field.Name]
+		if !byColumn && !byName {
+			omits = append(omits, field.DBName)
+		}
+	}
+	if len(omits) == len(db.Statement.Omits) {
+		return db
+	}
+	return db.Session(&gorm.Session{}).Omit(omits...)
+}
+
+// preservedConflict spells out the columns onConflict.UpdateAll stands for
+// when timestamps are preserved, so auto-update timestamps are taken from
+// the inserted rows rather than set to the current time on conflict.
+func (r *GenericRepository[T]) preservedConflict(db *gorm.DB, onConflict clause.OnConflict) (clause.OnConflict, error) {
+	if !r.preserveTimestamps || !onConflict.UpdateAll {
+		return onConflict, nil
+	}
+	s, err := parseSchema(db, new(T))
+	if err != nil {
+		return onConflict, err
+	}
+
+	// The columns gorm overwrites for UpdateAll
+	var columns []string
+	for _, field := range s.Fields {
+		if field.DBName == "" || !field.Creatable || field.PrimaryKey || field.AutoCreateTime > 0 ||
+			slices.Contains(db.Statement.Omits, field.DBName) {
+			continue
+		}
+		if field.HasDefaultValue && field.DefaultValueInterface == nil && !strings.EqualFold(field.DefaultValue, "NULL") {
+			continue
+		}
+		columns = append(columns, field.DBName)
+	}
+
+	onConflict.UpdateAll = false
+	onConflict.DoUpdates = clause.AssignmentColumns(columns)
+	onConflict.DoNothing = len(columns) == 0
+	if len(onConflict.Columns) == 0 {
+		for _, pk := range s.PrimaryFields {
+			onConflict.Columns = append(onConflict.Columns, clause.Column{Name: pk.DBName})
+		}
+	}
+	return onConflict, nil
+}
+
+// writeDB returns the connection writes of target should use.
+func (r *GenericRepository[T]) writeDB(target any) *gorm.DB {
+	if r.clock == nil && !r.preserveTimestamps {
+		return r.db
+	}
+
+	now := r.db.NowFunc
+	if r.clock != nil {
+		now = r.clock.Now
+	}
+
+	if r.preserveTimestamps {
+		if preserved, ok := r.entityUpdatedAt(target); ok {
+			now = func() time.Time { return preserved }
+		}
+	}
+
+	return r.db.Session(&gorm.Session{NowFunc: now})
+}
+
+// entityUpdatedAt reads the auto-update timestamp of a single entity.
+func (r *GenericRepository[T]) entityUpdatedAt(target any) (time.Time, bool) {
+	val := reflect.ValueOf(target)
+	if !val.IsValid() || val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return time.Time{}, false
+	}
+
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	for _, field := range s.Fields {
+		if field.AutoUpdateTime == 0 {
+			continue
+		}
+		value, isZero := field.ValueOf(r.context(), val.Elem())
+		if isZero {
+			return time.Time{}, false
+		}
+
+		switch v := value.(type) {
+		case time.Time:
+			return v, true
+		case *time.Time:
+			return *v, true
+		default:
+			n := reflect.ValueOf(v)
+			if !n.CanInt() {
+				return time.Time{}, false
+			}
+			switch field.AutoUpdateTime {
+			case schema.UnixNanosecond:
+				return time.Unix(0, n.Int()), true
+			case schema.UnixMillisecond:
+				return time.UnixMilli(n.Int()), true
+			default:
+				return time.Unix(n.Int(), 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,51 @@
+package gormrepo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type stampItem struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func TestPreservedTimestamps(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		write func(r *GenericRepository[stampItem]) error
+		want  string
+	}{
+		{"UpsertBatch", func(r *GenericRepository[stampItem]) error {
+			items := []stampItem{{ID: 1, Name: "a", CreatedAt: at, UpdatedAt: at}, {ID: 2, Name: "b", CreatedAt: at, UpdatedAt: at}}
+			return r.UpsertBatch(&items).Error()
+		}, `ON CONFLICT ("id") DO UPDATE SET "name"="excluded"."name","updated_at"="excluded"."updated_at" RETURNING "id" [`},
+		{"UpdateWhere", func(r *GenericRepository[stampItem]) error {
+			return r.Where("id = ?", 1).UpdateWhere(map[string]any{"name": "x"}).Error()
+		}, `UPDATE "stamp_items" SET "name"=? WHERE id = ? [x 1]`},
+		{"UpdateWhere setting it", func(r *GenericRepository[stampItem]) error {
+			return r.Where("id = ?", 1).UpdateWhere(map[string]any{"updated_at": at}).Error()
+		}, `UPDATE "stamp_items" SET "updated_at"=? WHERE id = ? [2020-01-01 00:00:00 +0000 UTC 1]`},
+		{"UpdateBatchFields", func(r *GenericRepository[stampItem]) error {
+			return r.UpdateBatchFields(map[int64]map[string]any{1: {"name": "y"}}).Error()
+		}, `UPDATE "stamp_items" SET "name"=CASE "id" WHEN ? THEN ? ELSE "name" END WHERE "id" = ? [1 y 1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newTestDB(t, "postgres", "16.2")
+			if err := tt.write(New[stampItem](db).TouchTimestamps(false)); err != nil {
+				t.Fatal(err)
+			}
+			got := fake.ranLike("stamp_items")
+			if len(got) != 1 || !strings.Contains(got[0], tt.want) {
+				t.Errorf("ran %q, want %s", got, tt.want)
+			}
+		})
+	}
+}
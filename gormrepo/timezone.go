@@ -0,0 +1,61 @@
+package gormrepo
+
+import (
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// WithLocation converts the time fields of loaded entities to loc, and those
+// of written entities and update maps to UTC.
+func (r *GenericRepository[T]) WithLocation(loc *time.Location) *GenericRepository[T] {
+	r.location = loc
+	return r
+}
+
+// convertTimes sets the time.Time and *time.Time fields of a struct,
+// including embedded ones, to loc.
+func convertTimes(val reflect.Value, loc *time.Location) {
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldVal := val.Field(i)
+
+		switch {
+		case field.Type == timeType:
+			fieldVal.Set(reflect.ValueOf(fieldVal.Interface().(time.Time).In(loc)))
+		case field.Type == reflect.PointerTo(timeType):
+			if !fieldVal.IsNil() {
+				t := fieldVal.Elem().Interface().(time.Time).In(loc)
+				fieldVal.Set(reflect.ValueOf(&t))
+			}
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			convertTimes(fieldVal, loc)
+		}
+	}
+}
+
+// utcFields returns fields with its time values converted to UTC.
+func (r *GenericRepository[T]) utcFields(fields map[string]interface{}) map[string]interface{} {
+	if r.location == nil {
+		return fields
+	}
+
+	converted := make(map[string]interface{}, len(fields))
+	for column, value := range fields {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case *time.Time:
+			if v != nil {
+				t := v.UTC()
+				value = &t
+			}
+		}
+		converted[column] = value
+	}
+	return converted
+}
@@ -0,0 +1,175 @@
+package gormrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultTxMaxRetries = 3
+
+type TxOptions struct {
+	Isolation                   sql.IsolationLevel
+	ReadOnly                    bool
+	RetryOnSerializationFailure bool
+	MaxRetries                  int // Defaults to 3 when RetryOnSerializationFailure is set
+}
+
+func (r *GenericRepository[T]) TransactionCtx(ctx context.Context, fn func(tx *GenericRepository[T]) error, opts TxOptions) error {
+	if r.readOptimized {
+		return ErrTransactionsUnsupported
+	}
+	sqlOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+
+	attempts := 1
+	if opts.RetryOnSerializationFailure {
+		retries := opts.MaxRetries
+		if retries <= 0 {
+			retries = defaultTxMaxRetries
+		}
+		attempts += retries
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+			}
+		}
+
+		txRepo := r.newTxRepository()
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txRepo.db = tx
+			return fn(txRepo)
+		}, sqlOpts)
+
+		if err == nil {
+			return r.afterCommit(txRepo)
+		}
+		err = r.afterRollback(txRepo, err)
+		if !isSerializationFailure(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// WithDeferredConstraints runs fn in a transaction whose constraint checks are
+// postponed until commit, so rows with circular foreign keys can be saved in
+// any order. Only DEFERRABLE constraints are affected on Postgres; MySQL has no
+// deferred checks, so foreign key checks are disabled for the transaction instead.
+func (r *GenericRepository[T]) WithDeferredConstraints(fn func(tx *GenericRepository[T]) error) error {
+	if r.db.Dialector.Name() == "mysql" && !inTransaction(r.db) {
+		// FOREIGN_KEY_CHECKS belongs to the session: the transaction runs on
+		// a pinned connection, discarded when the checks can't be restored
+		return r.db.Session(&gorm.Session{}).Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			err := r.clone(conn).Transaction(fn)
+			if resetErr := conn.Exec("SET FOREIGN_KEY_CHECKS = 1").Error; resetErr != nil {
+				discardConn(conn)
+				return errors.Join(err, fmt.Errorf("error restoring foreign key checks: %w", resetErr))
+			}
+			return err
+		})
+	}
+
+	return r.Transaction(func(tx *GenericRepository[T]) error {
+		switch name := tx.db.Dialector.Name(); name {
+		case "postgres":
+			if err := tx.db.Exec("SET CONSTRAINTS ALL DEFERRED").Error; err != nil {
+				return err
+			}
+		case "sqlite":
+			if err := tx.db.Exec("PRAGMA defer_foreign_keys = ON").Error; err != nil {
+				return err
+			}
+		case "mysql":
+			// Already in a transaction, whose connection can't be discarded
+			if err := tx.db.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			err := fn(tx)
+			if resetErr := tx.db.Exec("SET FOREIGN_KEY_CHECKS = 1").Error; resetErr != nil {
+				return errors.Join(err, fmt.Errorf("error restoring foreign key checks: %w", resetErr))
+			}
+			return err
+		default:
+			return fmt.Errorf("deferred constraints are not supported on %s", name)
+		}
+
+		return fn(tx)
+	})
+}
+
+type sqlStateError interface {
+	SQLState() string
+}
+
+func isSerializationFailure(err error) bool {
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		switch stateErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+
+	// Drivers that don't expose SQLSTATE (e.g. MySQL) still report it in the message
+	msg := err.Error()
+	return strings.Contains(msg, "40001") ||
+		strings.Contains(msg, "could not serialize access") ||
+		strings.Contains(msg, "Deadlock found")
+}
+
+// newTxRepository builds the repository handed to transaction callbacks, a
+// clone of r whose db is set once the transaction has started. The
+// transaction starts from r's chain, so its conditions, scopes and context
+// apply inside the callback too.
+func (r *GenericRepository[T]) newTxRepository() *GenericRepository[T] {
+	txRepo := r.clone(nil)
+	txRepo.pending = &pendingWork[T]{}
+	return txRepo
+}
+
+// afterCommit runs the work txRepo deferred until its transaction committed,
+// returning its errors as a committedError. Nested transactions hand it over
+// to the enclosing one instead.
+func (r *GenericRepository[T]) afterCommit(txRepo *GenericRepository[T]) error {
+	if r.pending != nil {
+		r.pending.add(txRepo.pending)
+		return nil
+	}
+	if err := r.committed(txRepo.pending); err != nil {
+		return &committedError{err}
+	}
+	return nil
+}
+
+// afterRollback reports the changes discarded by txRepo's transaction and
+// returns err, joined with any hook failure.
+func (r *GenericRepository[T]) afterRollback(txRepo *GenericRepository[T], err error) error {
+	if hookErr := r.rolledBack(txRepo.pending); hookErr != nil {
+		return errors.Join(err, hookErr)
+	}
+	return err
+}
+
+// discardConn closes the pinned connection of db instead of returning it to
+// the pool, for connections left in an unknown session state.
+func discardConn(db *gorm.DB) {
+	if conn, ok := db.Statement.ConnPool.(*sql.Conn); ok {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
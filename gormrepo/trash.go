@@ -0,0 +1,106 @@
+package gormrepo
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Trash limits the chain to soft-deleted rows, e.g.
+// repo.Trash().Where("owner_id = ?", id).Count(nil).
+func (r *GenericRepository[T]) Trash() *GenericRepository[T] {
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return r
+	}
+	deleted := softDeleteField(s)
+	if deleted == nil {
+		r.lastError = ErrNotSoftDeletable
+		return r
+	}
+	r.db = r.db.Unscoped().Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: deleted.DBName}, Value: nil})
+	return r
+}
+
+// ListTrashed returns the soft-deleted entities, most recently deleted
+// first unless the chain sets its own order.
+func (r *GenericRepository[T]) ListTrashed() (*[]T, error) {
+	r.Trash()
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if !hasOrder(r.db) {
+		s, _ := parseSchema(r.db, new(T))
+		r.db = r.db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: softDeleteField(s).DBName}, Desc: true})
+	}
+	return r.Get()
+}
+
+// PurgeOlderThan permanently deletes the rows soft deleted more than age
+// ago, in batches, and returns how many were deleted.
+func (r *GenericRepository[T]) PurgeOlderThan(age time.Duration) (int64, error) {
+	if r.lastError != nil {
+		return 0, r.lastError
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		return 0, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return 0, gorm.ErrPrimaryKeyRequired
+	}
+	deleted := softDeleteField(s)
+	if deleted == nil {
+		return 0, ErrNotSoftDeletable
+	}
+
+	now := r.db.NowFunc
+	if r.clock != nil {
+		now = r.clock.Now
+	}
+	expired := clause.Lt{Column: clause.Column{Table: clause.CurrentTable, Name: deleted.DBName}, Value: now().Add(-age)}
+	pk := s.PrioritizedPrimaryField
+
+	var purged int64
+	err = r.write(OpDelete, nil, func(db *gorm.DB) error {
+		for {
+			ids := reflect.New(reflect.SliceOf(pk.FieldType))
+			err := db.Session(&gorm.Session{}).Unscoped().Model(new(T)).
+				Where(expired).
+				Limit(conflictBatchSize).
+				Pluck(pk.DBName, ids.Interface()).Error
+			if err != nil {
+				return err
+			}
+			if ids.Elem().Len() == 0 {
+				return nil
+			}
+
+			res := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+				Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: toInterfaceSlice(ids.Elem())}).
+				Delete(new(T))
+			if res.Error != nil {
+				return res.Error
+			}
+			purged += res.RowsAffected
+			if ids.Elem().Len() < conflictBatchSize {
+				return nil
+			}
+		}
+	})
+	return purged, err
+}
+
+// TrashRetention returns a maintenance job purging the rows of T soft
+// deleted more than retention ago, e.g.
+// m.Register("purge-orders", "@daily", TrashRetention[Order](30*24*time.Hour)).
+func TrashRetention[T any](retention time.Duration) MaintenanceJob {
+	return RepositoryJob(func(ctx context.Context, repo *GenericRepository[T]) error {
+		_, err := repo.PurgeOlderThan(retention)
+		return err
+	})
+}
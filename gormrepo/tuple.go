@@ -0,0 +1,71 @@
+package gormrepo
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// WhereTupleIn matches rows whose columns equal one of the tuples in values,
+// e.g. WhereTupleIn([]string{"org_id", "user_id"}, [][]any{{1, 2}, {1, 3}})
+// for lookups by composite key. Dialects without row value comparisons get
+// the equivalent OR of ANDs.
+func (r *GenericRepository[T]) WhereTupleIn(columns []string, values [][]any) *GenericRepository[T] {
+	if len(columns) == 0 {
+		r.lastError = fmt.Errorf("tuple filter needs at least one column")
+		return r
+	}
+	for i, tuple := range values {
+		if len(tuple) != len(columns) {
+			r.lastError = fmt.Errorf("tuple %d has %d values for %d columns", i, len(tuple), len(columns))
+			return r
+		}
+	}
+
+	if len(values) == 0 {
+		r.db = r.db.Where(clause.Expr{SQL: "1 = 0"})
+		return r
+	}
+
+	switch r.db.Dialector.Name() {
+	case "postgres", "mysql", "sqlite":
+		r.db = r.db.Where(tupleIn(columns, values))
+	default:
+		r.db = r.db.Where(tupleOr(columns, values))
+	}
+	return r
+}
+
+// tupleIn builds (a, b) IN ((?, ?), (?, ?)).
+func tupleIn(columns []string, values [][]any) clause.Expr {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	vars := make([]interface{}, 0, len(columns)*(len(values)+1))
+	for _, column := range columns {
+		vars = append(vars, clause.Column{Name: column})
+	}
+	tuples := make([]string, len(values))
+	for i, tuple := range values {
+		tuples[i] = placeholders
+		vars = append(vars, tuple...)
+	}
+
+	return clause.Expr{
+		SQL:  fmt.Sprintf("%s IN (%s)", placeholders, strings.Join(tuples, ", ")),
+		Vars: vars,
+	}
+}
+
+// tupleOr builds (a = ? AND b = ?) OR (a = ? AND b = ?).
+func tupleOr(columns []string, values [][]any) clause.Expression {
+	tuples := make([]clause.Expression, len(values))
+	for i, tuple := range values {
+		eqs := make([]clause.Expression, len(columns))
+		for j, column := range columns {
+			eqs[j] = clause.Eq{Column: clause.Column{Name: column}, Value: tuple[j]}
+		}
+		tuples[i] = clause.And(eqs...)
+	}
+	return clause.Or(tuples...)
+}
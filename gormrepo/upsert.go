@@ -0,0 +1,371 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// UpsertBuilder configures the ON CONFLICT behaviour of an upsert. Without
+// any option every column is overwritten on a primary key conflict.
+type UpsertBuilder[T any] struct {
+	repo            *GenericRepository[T]
+	conflictColumns []string
+	updateColumns   []string
+	doNothing       bool
+}
+
+// Deprecated: use Exec().Upsert, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) Upsert(entity *T) *GenericRepository[T] {
+	return r.OnConflictColumns().Upsert(entity)
+}
+
+// Deprecated: use Exec().UpsertBatch, which returns the outcome instead of
+// keeping it in the repository.
+func (r *GenericRepository[T]) UpsertBatch(entities *[]T) *GenericRepository[T] {
+	return r.OnConflictColumns().UpsertBatch(entities)
+}
+
+// Upsert inserts entity, or overwrites every column of the row with its
+// primary key. OnConflictColumns configures other conflicts.
+func (e *Executor[T]) Upsert(entity *T) ExecutionResult[T] {
+	return e.repo.OnConflictColumns().ExecUpsert(entity)
+}
+
+func (e *Executor[T]) UpsertBatch(entities *[]T) ExecutionResult[T] {
+	return e.repo.OnConflictColumns().ExecUpsertBatch(entities)
+}
+
+func (r *GenericRepository[T]) OnConflictColumns(columns ...string) *UpsertBuilder[T] {
+	return &UpsertBuilder[T]{repo: r, conflictColumns: columns}
+}
+
+func (b *UpsertBuilder[T]) UpdateOnly(columns ...string) *UpsertBuilder[T] {
+	b.updateColumns = append(b.updateColumns, columns...)
+	b.doNothing = false
+	return b
+}
+
+func (b *UpsertBuilder[T]) DoNothingIfConflict() *UpsertBuilder[T] {
+	b.doNothing = true
+	b.updateColumns = nil
+	return b
+}
+
+// Deprecated: use ExecUpsert, which returns the outcome instead of keeping
+// it in the repository.
+func (b *UpsertBuilder[T]) Upsert(entity *T) *GenericRepository[T] {
+	return b.repo.keep(b.ExecUpsert(entity))
+}
+
+// Deprecated: use ExecUpsertBatch, which returns the outcome instead of
+// keeping it in the repository.
+func (b *UpsertBuilder[T]) UpsertBatch(entities *[]T) *GenericRepository[T] {
+	return b.repo.keep(b.ExecUpsertBatch(entities))
+}
+
+func (b *UpsertBuilder[T]) ExecUpsert(entity *T) ExecutionResult[T] {
+	r := b.repo
+	if err := r.prepare(OpUpsert, entity); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	err := r.write(OpUpsert, entity, func(db *gorm.DB) error {
+		onConflict, err := r.ownedConflict(db, b.clause())
+		if err != nil {
+			return err
+		}
+		if onConflict, err = r.preservedConflict(db, onConflict); err != nil {
+			return err
+		}
+		if db, err = r.loadedOnly(db); err != nil {
+			return err
+		}
+		res := db.Clauses(onConflict).Create(entity)
+		rows = res.RowsAffected
+		return res.Error
+	})
+	return executionResult(entity, rows, err)
+}
+
+func (b *UpsertBuilder[T]) ExecUpsertBatch(entities *[]T) ExecutionResult[T] {
+	r := b.repo
+	if err := r.prepare(OpUpsert, entities); err != nil {
+		return ExecutionResult[T]{Err: err}
+	}
+
+	var rows int64
+	err := r.write(OpUpsert, entities, func(db *gorm.DB) error {
+		onConflict, err := r.ownedConflict(db, b.clause())
+		if err != nil {
+			return err
+		}
+		if onConflict, err = r.preservedConflict(db, onConflict); err != nil {
+			return err
+		}
+		if db, err = r.loadedOnly(db); err != nil {
+			return err
+		}
+		res := db.Clauses(onConflict).Create(entities)
+		rows = res.RowsAffected
+		return res.Error
+	})
+	return batchResult(entities, rows, err)
+}
+
+func (b *UpsertBuilder[T]) clause() clause.OnConflict {
+	onConflict := clause.OnConflict{}
+	for _, column := range b.conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+
+	switch {
+	case b.doNothing:
+		onConflict.DoNothing = true
+	case len(b.updateColumns) > 0:
+		onConflict.DoUpdates = clause.AssignmentColumns(b.updateColumns)
+	default:
+		onConflict.UpdateAll = true
+	}
+
+	return onConflict
+}
+
+var errConflictSkipped = errors.New("row skipped on conflict")
+
+type BatchInsertResult[T any] struct {
+	Inserted []*T
+	Skipped  []*T // Rows that already existed and were left untouched
+}
+
+// CreateBatchIgnoreConflicts inserts the entities with ON CONFLICT DO NOTHING
+// (INSERT IGNORE semantics on MySQL) inside a single transaction and reports
+// which rows were actually written. Dialects with RETURNING other than MySQL
+// insert up to 500 rows per statement; elsewhere, and for entities with
+// associations, create hooks or recorded events, each row is inserted on
+// its own.
+func (r *GenericRepository[T]) CreateBatchIgnoreConflicts(entities *[]T) (*BatchInsertResult[T], error) {
+	if r.lastError != nil {
+		return nil, r.lastError
+	}
+	if err := r.prepare(OpCreate, entities); err != nil {
+		r.lastError = err
+		return nil, err
+	}
+	s, err := parseSchema(r.db, new(T))
+	if err != nil {
+		r.lastError = err
+		return nil, err
+	}
+
+	result := &BatchInsertResult[T]{}
+	err = r.Transaction(func(tx *GenericRepository[T]) error {
+		if !tx.insertsIgnoringConflicts(s, entities) {
+			return tx.createEachIgnoringConflicts(entities, result)
+		}
+
+		size := max(1, min(conflictBatchSize, maxInsertParams/max(1, len(s.DBNames))))
+		for start := 0; start < len(*entities); start += size {
+			batch := make([]*T, 0, size)
+			for i := start; i < min(start+size, len(*entities)); i++ {
+				batch = append(batch, &(*entities)[i])
+			}
+			inserted, err := tx.insertIgnoringConflicts(s, batch)
+			if err != nil {
+				return err
+			}
+			for _, entity := range batch {
+				if slices.Contains(inserted, entity) {
+					result.Inserted = append(result.Inserted, entity)
+				} else {
+					result.Skipped = append(result.Skipped, entity)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.lastError = err
+		return nil, err
+	}
+
+	r.currentSlice = entities
+	return result, nil
+}
+
+// maxInsertParams keeps multi-row inserts under the parameter limits of
+// Postgres and SQLite.
+const maxInsertParams = 32000
+
+// insertsIgnoringConflicts reports whether entities can be inserted with one
+// statement per batch: the dialect returns the inserted rows, and nothing
+// but their columns has to be written or reported per row.
+func (r *GenericRepository[T]) insertsIgnoringConflicts(s *schema.Schema, entities *[]T) bool {
+	caps := DetectCapabilities(r.db)
+	if caps.Dialect == "mysql" || !caps.Returning || !caps.Upsert {
+		return false
+	}
+	if len(s.Relationships.Relations) > 0 || s.BeforeSave || s.BeforeCreate || s.AfterCreate || s.AfterSave {
+		return false
+	}
+	return len(eventRecorders(entities)) == 0
+}
+
+// createEachIgnoringConflicts inserts the entities one by one.
+func (r *GenericRepository[T]) createEachIgnoringConflicts(entities *[]T, result *BatchInsertResult[T]) error {
+	for i := range *entities {
+		entity := &(*entities)[i]
+		err := r.write(OpCreate, entity, func(db *gorm.DB) error {
+			res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(entity)
+			if res.Error == nil && res.RowsAffected == 0 {
+				return errConflictSkipped
+			}
+			return res.Error
+		})
+
+		switch {
+		case errors.Is(err, errConflictSkipped):
+			result.Skipped = append(result.Skipped, entity)
+		case err != nil:
+			return err
+		default:
+			result.Inserted = append(result.Inserted, entity)
+		}
+	}
+	return nil
+}
+
+// insertIgnoringConflicts inserts batch with one statement and returns the
+// entities inserted, which alone are seen as written by what follows the
+// write. The rows the statement returns, in the order of batch, are matched
+// to the entities by primary key or unique columns.
+func (r *GenericRepository[T]) insertIgnoringConflicts(s *schema.Schema, batch []*T) ([]*T, error) {
+	keys := uniqueKeys(s)
+	returned := map[*schema.Field]bool{}
+	for _, key := range keys {
+		for _, field := range key {
+			returned[field] = true
+		}
+	}
+	for _, field := range s.FieldsWithDefaultDBValue {
+		returned[field] = true
+	}
+	returning := clause.Returning{}
+	for _, field := range s.Fields {
+		if returned[field] {
+			returning.Columns = append(returning.Columns, clause.Column{Name: field.DBName})
+		}
+	}
+
+	inserted := slices.Clone(batch)
+	err := r.write(OpCreate, &inserted, func(db *gorm.DB) error {
+		// Built without running, so the returned rows aren't assigned to the
+		// entities in order as if none had been skipped
+		stmt := db.Session(&gorm.Session{DryRun: true}).
+			Clauses(clause.OnConflict{DoNothing: true}, returning).
+			Create(&inserted)
+		if stmt.Error != nil {
+			return stmt.Error
+		}
+		rows, err := db.Session(&gorm.Session{NewDB: true}).
+			Raw(stmt.Statement.SQL.String(), stmt.Statement.Vars...).
+			Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		ctx := r.context()
+		matched := inserted[:0:0]
+		next := 0
+		for rows.Next() {
+			var row T
+			if err := db.ScanRows(rows, &row); err != nil {
+				return err
+			}
+			stored := reflect.ValueOf(&row).Elem()
+			for next < len(batch) && !sameRow(ctx, keys, reflect.ValueOf(batch[next]).Elem(), stored) {
+				next++
+			}
+			if next == len(batch) {
+				return fmt.Errorf("inserted %s row matches no entity of the batch", s.Name)
+			}
+			entity := reflect.ValueOf(batch[next]).Elem()
+			for field := range returned {
+				value, _ := field.ValueOf(ctx, stored)
+				if err := field.Set(ctx, entity, value); err != nil {
+					return err
+				}
+			}
+			matched = append(matched, batch[next])
+			next++
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		inserted = matched
+		return nil
+	})
+	return inserted, err
+}
+
+// uniqueKeys returns the primary key and the unique columns and indexes of
+// s, the column sets an insert can conflict on.
+func uniqueKeys(s *schema.Schema) [][]*schema.Field {
+	var keys [][]*schema.Field
+	if len(s.PrimaryFields) > 0 {
+		keys = append(keys, s.PrimaryFields)
+	}
+	for _, field := range s.Fields {
+		if field.Unique && field.DBName != "" {
+			keys = append(keys, []*schema.Field{field})
+		}
+	}
+	for _, index := range s.ParseIndexes() {
+		if index.Class != "UNIQUE" {
+			continue
+		}
+		var key []*schema.Field
+		for _, option := range index.Fields {
+			if option.Field != nil {
+				key = append(key, option.Field)
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// sameRow reports whether stored can be the row inserted for entity: they
+// agree on every unique key entity sets. An entity setting none can't have
+// conflicted, so any row is its.
+func sameRow(ctx context.Context, keys [][]*schema.Field, entity, stored reflect.Value) bool {
+	for _, key := range keys {
+		set := true
+		for _, field := range key {
+			if _, isZero := field.ValueOf(ctx, entity); isZero {
+				set = false
+				break
+			}
+		}
+		if !set {
+			continue
+		}
+		for _, field := range key {
+			want, _ := field.ValueOf(ctx, entity)
+			got, _ := field.ValueOf(ctx, stored)
+			if fmt.Sprint(columnValue(want)) != fmt.Sprint(columnValue(got)) {
+				return false
+			}
+		}
+	}
+	return true
+}
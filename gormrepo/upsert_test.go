@@ -0,0 +1,50 @@
+package gormrepo
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type uniqueItem struct {
+	ID    int64
+	Email string `gorm:"unique"`
+	Name  string
+}
+
+func TestCreateBatchIgnoreConflictsInsertsOnce(t *testing.T) {
+	db, fake := newTestDB(t, "postgres", "16.2")
+	r := New[uniqueItem](db)
+
+	// The row of "b" already exists, so only those of "a" and "c" come back
+	fake.queue([]string{"id", "email"}, []driver.Value{int64(10), "a"}, []driver.Value{int64(11), "c"})
+	items := []uniqueItem{{Email: "a"}, {Email: "b"}, {Email: "c"}}
+	result, err := r.CreateBatchIgnoreConflicts(&items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fake.ranLike("INSERT"); len(got) != 1 || !strings.Contains(got[0], `ON CONFLICT DO NOTHING RETURNING "id","email"`) {
+		t.Errorf("ran %q, want one insert returning the keys", got)
+	}
+	if len(result.Inserted) != 2 || result.Inserted[0] != &items[0] || result.Inserted[1] != &items[2] {
+		t.Errorf("inserted %v", result.Inserted)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != &items[1] {
+		t.Errorf("skipped %v", result.Skipped)
+	}
+	if items[0].ID != 10 || items[1].ID != 0 || items[2].ID != 11 {
+		t.Errorf("got %+v", items)
+	}
+}
+
+func TestCreateBatchIgnoreConflictsInsertsRowsOnMySQL(t *testing.T) {
+	db, fake := newTestDB(t, "mysql", "8.0.36")
+	items := []uniqueItem{{Email: "a"}, {Email: "b"}}
+	if _, err := New[uniqueItem](db).CreateBatchIgnoreConflicts(&items); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.ranLike("INSERT"); len(got) != 2 {
+		t.Errorf("ran %q, want an insert per row", got)
+	}
+}
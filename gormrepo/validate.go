@@ -0,0 +1,136 @@
+package gormrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidEnum = errors.New("invalid enum value")
+
+// Enum is implemented by field types that restrict their values, as an
+// alternative to the `enum:"a,b,c"` tag.
+type Enum interface {
+	EnumValues() []string
+}
+
+type EnumError struct {
+	Field   string
+	Value   string
+	Allowed []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %q for field %s: allowed values are %s", e.Value, e.Field, strings.Join(e.Allowed, ", "))
+}
+
+func (e *EnumError) Is(target error) bool {
+	return target == ErrInvalidEnum
+}
+
+// validateEnums checks the enum fields of an entity or slice of entities.
+func validateEnums(target any) error {
+	return forEachEntity(target, validateStructEnums)
+}
+
+func validateStructEnums(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldVal := val.Field(i)
+
+		if field.Anonymous && fieldVal.Kind() == reflect.Struct {
+			if err := validateStructEnums(fieldVal); err != nil {
+				return err
+			}
+			continue
+		}
+
+		allowed := enumValues(field.Type, field.Tag)
+		if allowed == nil {
+			continue
+		}
+
+		if fieldVal.Kind() == reflect.Ptr {
+			if fieldVal.IsNil() {
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		// Zero values of columns with a database default are left out of inserts
+		if fieldVal.IsZero() && strings.Contains(field.Tag.Get("gorm"), "default:") {
+			continue
+		}
+
+		if err := checkEnum(field.Name, fieldVal.Interface(), allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEnumFields checks column/value maps used by partial updates.
+func validateEnumFields(db *gorm.DB, model any, fields map[string]interface{}) error {
+	s, err := parseSchema(db, model)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range fields {
+		field := s.LookUpField(name)
+		if field == nil {
+			continue
+		}
+		allowed := enumValues(field.FieldType, field.Tag)
+		if allowed == nil {
+			continue
+		}
+		if _, isExpr := value.(clause.Expression); isExpr {
+			continue
+		}
+		if err := checkEnum(field.Name, value, allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func enumValues(t reflect.Type, tag reflect.StructTag) []string {
+	if values := tag.Get("enum"); values != "" {
+		allowed := strings.Split(values, ",")
+		for i := range allowed {
+			allowed[i] = strings.TrimSpace(allowed[i])
+		}
+		return allowed
+	}
+
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PointerTo(t)
+	}
+	if t.Implements(reflect.TypeOf((*Enum)(nil)).Elem()) {
+		return reflect.New(t.Elem()).Interface().(Enum).EnumValues()
+	}
+	return nil
+}
+
+func checkEnum(field string, value any, allowed []string) error {
+	str := fmt.Sprint(value)
+	if stringer, ok := value.(fmt.Stringer); ok {
+		str = stringer.String()
+	}
+
+	for _, candidate := range allowed {
+		if str == candidate {
+			return nil
+		}
+	}
+	return &EnumError{Field: field, Value: str, Allowed: allowed}
+}